@@ -2,32 +2,36 @@ package main
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/base64"
 	"errors"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
-	"github.com/google/uuid"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 
 	"github.com/ilcm96/gh-copilot-proxy/internal/auth"
+	"github.com/ilcm96/gh-copilot-proxy/internal/httpx"
 	"github.com/ilcm96/gh-copilot-proxy/internal/proxy"
+	"github.com/ilcm96/gh-copilot-proxy/internal/usage"
 )
 
-// generateAccessToken creates a server token using cryptographically secure random bytes.
-// generateAccessToken 는 안전한 임의 바이트를 사용해 서버용 토큰을 생성합니다.
-func generateAccessToken() string {
-	buf := make([]byte, 32)
-	if _, err := rand.Read(buf); err != nil {
-		return uuid.NewString()
+// envInt reads a non-negative integer environment variable, treating an empty value as zero.
+// envInt 는 음수가 아닌 정수 환경 변수를 읽으며, 비어 있으면 0 으로 봅니다.
+func envInt(name string) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0
 	}
-	return base64.RawURLEncoding.EncodeToString(buf)
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Fatalf("%s must be a non-negative integer, got %q", name, value)
+	}
+	return n
 }
 
 // main initializes and runs the Copilot proxy server.
@@ -38,7 +42,19 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	authenticator, err := auth.NewCopilotAuth(ctx)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "login":
+			if err := auth.Login(ctx, os.Stdout); err != nil {
+				log.Fatalf("login: %v", err)
+			}
+			return
+		default:
+			log.Fatalf("unknown command %q", os.Args[1])
+		}
+	}
+
+	authenticator, err := auth.NewPool(ctx)
 	if err != nil {
 		log.Fatalf("init auth: %v", err)
 	}
@@ -47,14 +63,82 @@ func main() {
 	}
 	defer authenticator.Cleanup()
 
+	adminToken := os.Getenv("ADMIN_API_KEY")
+	var keys *proxy.KeyStore
+	if path := os.Getenv("API_KEYS_FILE"); path != "" {
+		keys, err = proxy.NewKeyStore(path)
+		if err != nil {
+			log.Fatalf("load key file: %v", err)
+		}
+		defer func() {
+			if err := keys.Flush(); err != nil {
+				log.Printf("key file write failed: %v", err)
+			}
+		}()
+		go keys.Watch(ctx)
+	}
+
+	var ledger *usage.Ledger
+	if path := os.Getenv("USAGE_LEDGER"); path != "" {
+		ledger, err = usage.Open(path)
+		if err != nil {
+			log.Fatalf("open usage ledger: %v", err)
+		}
+		defer ledger.Close()
+	}
+	if ledger == nil && keys != nil {
+		for _, key := range keys.List() {
+			if key.Quota != nil {
+				log.Fatalf("key %q has a quota, which requires USAGE_LEDGER", key.Name)
+			}
+		}
+	}
+
+	// Only a generated key is logged; configured secrets never reach the log.
+	// 생성한 키만 로그에 남기며, 설정으로 받은 비밀 값은 로그에 남기지 않습니다.
 	apiKey := os.Getenv("API_KEY")
-	if apiKey == "" {
-		apiKey = generateAccessToken()
+	if apiKey == "" && keys == nil {
+		apiKey = proxy.GenerateKey()
 		_ = os.Setenv("API_KEY", apiKey)
+		log.Printf("generated API key: %s", apiKey)
+	}
+
+	rateLimit := proxy.RateLimit{
+		RequestsPerMinute: envInt("RATE_LIMIT_REQUESTS_PER_MINUTE"),
+		TokensPerMinute:   envInt("RATE_LIMIT_TOKENS_PER_MINUTE"),
+	}
+
+	tlsCert, tlsKey := os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE")
+	clientCA := os.Getenv("TLS_CLIENT_CA_FILE")
+	if (tlsCert == "") != (tlsKey == "") {
+		log.Fatalf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if clientCA != "" && tlsCert == "" {
+		log.Fatalf("TLS_CLIENT_CA_FILE requires TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+	certIdentity := os.Getenv("CLIENT_CERT_IDENTITY") == "true"
+	if certIdentity && clientCA == "" {
+		log.Fatalf("CLIENT_CERT_IDENTITY requires TLS_CLIENT_CA_FILE")
+	}
+	certBindKeys := os.Getenv("CLIENT_CERT_BIND_KEYS") == "true"
+	if certBindKeys && clientCA == "" {
+		log.Fatalf("CLIENT_CERT_BIND_KEYS requires TLS_CLIENT_CA_FILE")
+	}
+	if certBindKeys && certIdentity {
+		log.Fatalf("CLIENT_CERT_BIND_KEYS and CLIENT_CERT_IDENTITY cannot be used together")
 	}
-	log.Printf("API key: %s", apiKey)
 
-	srv := proxy.NewProxyServer(authenticator, apiKey)
+	srv := proxy.NewProxyServer(authenticator, apiKey, proxy.Config{
+		UserTokenHeader: os.Getenv("COPILOT_USER_TOKEN_HEADER"),
+		Keys:            keys,
+		AdminToken:      adminToken,
+		RateLimit:       rateLimit,
+		Usage:           ledger,
+		ModelsTTL:       time.Duration(envInt("MODELS_CACHE_TTL_SECONDS")) * time.Second,
+
+		ClientCertIdentity: certIdentity,
+		ClientCertBindKeys: certBindKeys,
+	})
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -63,12 +147,31 @@ func main() {
 	addr := ":" + port
 
 	baseHandler := srv.Routes()
+	if spec := os.Getenv("ALLOWED_CIDRS"); spec != "" {
+		prefixes, err := httpx.ParseCIDRs(spec)
+		if err != nil {
+			log.Fatalf("ALLOWED_CIDRS: %v", err)
+		}
+		baseHandler = httpx.WithAllowlist(prefixes, baseHandler)
+	}
 	server := &http.Server{
 		Addr:    addr,
 		Handler: h2c.NewHandler(baseHandler, &http2.Server{}),
 	}
+	if tlsCert != "" {
+		server.TLSConfig, err = httpx.ServerTLSConfig(clientCA)
+		if err != nil {
+			log.Fatalf("tls config: %v", err)
+		}
+	}
 
+	// Shutdown returns once in-flight requests finish; main waits for it so the deferred ledger
+	// close and cleanup run after the last request has been recorded.
+	// Shutdown 은 처리 중인 요청이 끝나야 반환되므로, main 은 이를 기다려 마지막 요청이 기록된 뒤에
+	// 지연된 원장 닫기와 정리 작업을 실행합니다.
+	shutdownDone := make(chan struct{})
 	go func() {
+		defer close(shutdownDone)
 		<-ctx.Done()
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -78,7 +181,13 @@ func main() {
 	}()
 
 	log.Printf("Listening on %s", addr)
-	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+	if tlsCert != "" {
+		err = server.ListenAndServeTLS(tlsCert, tlsKey)
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatalf("server error: %v", err)
 	}
+	<-shutdownDone
 }
@@ -4,7 +4,11 @@ go 1.25.3
 
 require (
 	github.com/google/uuid v1.6.0
+	github.com/tiktoken-go/tokenizer v0.7.0
 	golang.org/x/net v0.46.0
 )
 
-require golang.org/x/text v0.30.0 // indirect
+require (
+	github.com/dlclark/regexp2 v1.11.5 // indirect
+	golang.org/x/text v0.30.0 // indirect
+)
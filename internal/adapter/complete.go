@@ -0,0 +1,189 @@
+package adapter
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// humanStop is the turn marker the legacy Anthropic completions API always stops at.
+// humanStop 는 레거시 Anthropic completions API 가 항상 멈추는 차례 표시입니다.
+const humanStop = "\n\nHuman:"
+
+// turnMarker matches the Human and Assistant turn markers of a legacy Anthropic prompt.
+// turnMarker 는 레거시 Anthropic 프롬프트의 Human, Assistant 차례 표시와 일치합니다.
+var turnMarker = regexp.MustCompile(`\n\n(Human|Assistant):`)
+
+// ConvertRequestCompleteToOpenAI converts an Anthropic legacy completions request into a chat/completions request.
+// ConvertRequestCompleteToOpenAI 는 Anthropic 레거시 completions 요청을 chat/completions 요청으로 변환합니다.
+func ConvertRequestCompleteToOpenAI(body map[string]any) map[string]any {
+	result := map[string]any{
+		"model":    body["model"],
+		"messages": completePromptMessages(toString(body["prompt"])),
+	}
+	if stream, _ := body["stream"].(bool); stream {
+		result["stream"] = true
+		result["stream_options"] = map[string]any{"include_usage": true}
+	}
+	if maxTokens, ok := body["max_tokens_to_sample"]; ok && maxTokens != nil {
+		result["max_tokens"] = maxTokens
+	}
+	for _, key := range []string{"temperature", "top_p"} {
+		if value, ok := body[key]; ok && value != nil {
+			result[key] = value
+		}
+	}
+	result["stop"] = upstreamStops(completeStops(body))
+	return result
+}
+
+// completePromptMessages splits a "\n\nHuman: … \n\nAssistant:" prompt into chat messages.
+// completePromptMessages 는 "\n\nHuman: … \n\nAssistant:" 프롬프트를 chat 메시지로 나눕니다.
+//
+// Text before the first marker becomes the system prompt, and a non-empty final Assistant turn is kept as a prefill.
+// 첫 표시 앞의 텍스트는 시스템 프롬프트가 되며, 비어 있지 않은 마지막 Assistant 차례는 미리 채운 응답으로 남깁니다.
+func completePromptMessages(prompt string) []any {
+	markers := turnMarker.FindAllStringSubmatchIndex(prompt, -1)
+	if len(markers) == 0 {
+		return []any{map[string]any{"role": "user", "content": strings.TrimSpace(prompt)}}
+	}
+	var messages []any
+	if system := strings.TrimSpace(prompt[:markers[0][0]]); system != "" {
+		messages = append(messages, map[string]any{"role": "system", "content": system})
+	}
+	for i, m := range markers {
+		end := len(prompt)
+		if i+1 < len(markers) {
+			end = markers[i+1][0]
+		}
+		role := "user"
+		if prompt[m[2]:m[3]] == "Assistant" {
+			role = "assistant"
+		}
+		content := strings.TrimSpace(prompt[m[1]:end])
+		if content == "" {
+			continue
+		}
+		messages = append(messages, map[string]any{"role": role, "content": content})
+	}
+	return messages
+}
+
+// completeStops returns the request's stop_sequences plus the implicit Human turn marker.
+// completeStops 는 요청의 stop_sequences 에 암묵적인 Human 차례 표시를 더해 반환합니다.
+func completeStops(request map[string]any) []string {
+	return append([]string{humanStop}, stopSequences(request["stop_sequences"])...)
+}
+
+// TransformOpenAIResponseToComplete writes a Copilot chat/completions response as an Anthropic legacy completion.
+// TransformOpenAIResponseToComplete 는 Copilot chat/completions 응답을 Anthropic 레거시 completion 으로 작성합니다.
+func TransformOpenAIResponseToComplete(w http.ResponseWriter, resp *http.Response, request map[string]any) error {
+	CopyAnthropicHeaders(w.Header(), resp.Header, resp.StatusCode, time.Now())
+
+	id := "compl_" + randomHex()
+	stops := &stopScanner{stops: completeStops(request)}
+
+	contentType := strings.ToLower(resp.Header.Get("Content-Type"))
+	if !strings.Contains(contentType, "text/event-stream") {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		var payload map[string]any
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return err
+		}
+		text, finishReason := chatText(payload)
+		text = stops.feed(text) + stops.flush()
+		data, err := json.Marshal(completeObject(id, payload["model"], text, finishReason, stops))
+		if err != nil {
+			return err
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(resp.StatusCode)
+		_, err = w.Write(data)
+		return err
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(resp.StatusCode)
+	flusher, _ := w.(http.Flusher)
+	send := func(event string, payload map[string]any) error {
+		data, err := marshalEventPayload(payload)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(buildEvent(event, data)); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+
+	model := request["model"]
+	finishReason := ""
+	if err := send("ping", map[string]any{"type": "ping"}); err != nil {
+		return err
+	}
+	err := eachChatChunk(resp.Body, func(chunk map[string]any) error {
+		if errPayload, ok := chunk["error"].(map[string]any); ok {
+			message := toString(errPayload["message"])
+			if message == "" {
+				message = stringifyJSON(errPayload)
+			}
+			return send("error", map[string]any{
+				"type":  "error",
+				"error": map[string]any{"type": "api_error", "message": message},
+			})
+		}
+		if m := toString(chunk["model"]); m != "" {
+			model = m
+		}
+		text, reason := chatText(chunk)
+		if reason != "" {
+			finishReason = reason
+		}
+		if text = stops.feed(text); text == "" {
+			return nil
+		}
+		return send("completion", map[string]any{
+			"type":        "completion",
+			"id":          id,
+			"completion":  text,
+			"stop_reason": nil,
+			"model":       model,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return send("completion", completeObject(id, model, stops.flush(), finishReason, stops))
+}
+
+// completeObject returns a finished legacy Anthropic completion.
+// completeObject 는 완료된 레거시 Anthropic completion 을 반환합니다.
+func completeObject(id string, model any, text, finishReason string, stops *stopScanner) map[string]any {
+	stopReason := "stop_sequence"
+	if finishReason == "length" && stops.matched == "" {
+		stopReason = "max_tokens"
+	}
+	var stop any
+	if stops.matched != "" {
+		stop = stops.matched
+	}
+	return map[string]any{
+		"type":        "completion",
+		"id":          id,
+		"completion":  text,
+		"stop_reason": stopReason,
+		"stop":        stop,
+		"model":       model,
+	}
+}
@@ -0,0 +1,143 @@
+package adapter
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompletePromptMessages(t *testing.T) {
+	tests := []struct {
+		name   string
+		prompt string
+		want   string
+	}{
+		{
+			name:   "single turn",
+			prompt: "\n\nHuman: Hello\n\nAssistant:",
+			want:   `[{"role":"user","content":"Hello"}]`,
+		},
+		{
+			name:   "text before the first turn is the system prompt",
+			prompt: "You are terse.\n\nHuman: Hi\n\nAssistant: Hello\n\nHuman: Bye\n\nAssistant:",
+			want:   `[{"role":"system","content":"You are terse."},{"role":"user","content":"Hi"},{"role":"assistant","content":"Hello"},{"role":"user","content":"Bye"}]`,
+		},
+		{
+			name:   "final assistant text is kept as a prefill",
+			prompt: "\n\nHuman: Name a color\n\nAssistant: Blue is",
+			want:   `[{"role":"user","content":"Name a color"},{"role":"assistant","content":"Blue is"}]`,
+		},
+		{
+			name:   "prompt without markers is one user message",
+			prompt: "  just text  ",
+			want:   `[{"role":"user","content":"just text"}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertJSON(t, completePromptMessages(tt.prompt), tt.want)
+		})
+	}
+}
+
+func TestConvertRequestCompleteToOpenAI(t *testing.T) {
+	body := map[string]any{
+		"model":                "claude-sonnet-4",
+		"prompt":               "\n\nHuman: Hi\n\nAssistant:",
+		"max_tokens_to_sample": 20,
+		"stop_sequences":       []any{"END"},
+		"top_k":                5,
+		"stream":               true,
+	}
+	assertJSON(t, ConvertRequestCompleteToOpenAI(body), `{
+		"model": "claude-sonnet-4",
+		"messages": [{"role":"user","content":"Hi"}],
+		"max_tokens": 20,
+		"stop": ["\n\nHuman:","END"],
+		"stream": true,
+		"stream_options": {"include_usage":true}
+	}`)
+}
+
+func TestTransformOpenAIResponseToComplete(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		finishReason string
+		want         string
+	}{
+		{
+			name:         "stops at the next human turn",
+			content:      "Hello\n\nHuman: more",
+			finishReason: "stop",
+			want:         `{"completion":"Hello","stop_reason":"stop_sequence","stop":"\n\nHuman:"}`,
+		},
+		{
+			name:         "stops at a requested sequence",
+			content:      "one END two",
+			finishReason: "stop",
+			want:         `{"completion":"one ","stop_reason":"stop_sequence","stop":"END"}`,
+		},
+		{
+			name:         "length maps to max_tokens",
+			content:      "truncated",
+			finishReason: "length",
+			want:         `{"completion":"truncated","stop_reason":"max_tokens","stop":null}`,
+		},
+	}
+	request := map[string]any{"model": "claude-sonnet-4", "stop_sequences": []any{"END"}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream, _ := json.Marshal(map[string]any{
+				"model": "claude-sonnet-4",
+				"choices": []any{map[string]any{
+					"message":       map[string]any{"role": "assistant", "content": tt.content},
+					"finish_reason": tt.finishReason,
+				}},
+			})
+			rec := httptest.NewRecorder()
+			if err := TransformOpenAIResponseToComplete(rec, upstreamResponse("application/json", string(upstream)), request); err != nil {
+				t.Fatalf("TransformOpenAIResponseToComplete() error = %v", err)
+			}
+			var got map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("invalid body %s: %v", rec.Body, err)
+			}
+			if got["type"] != "completion" || got["model"] != "claude-sonnet-4" {
+				t.Errorf("type = %v, model = %v", got["type"], got["model"])
+			}
+			delete(got, "type")
+			delete(got, "id")
+			delete(got, "model")
+			assertJSON(t, got, tt.want)
+		})
+	}
+}
+
+func TestTransformOpenAIResponseToCompleteStream(t *testing.T) {
+	request := map[string]any{"model": "claude-sonnet-4", "stream": true}
+	upstream := "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi \"}}]}\n\n" +
+		"data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"there\\n\\nHu\"}}]}\n\n" +
+		"data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"man: x\"},\"finish_reason\":\"stop\"}]}\n\n" +
+		"data: [DONE]\n\n"
+	rec := httptest.NewRecorder()
+	if err := TransformOpenAIResponseToComplete(rec, upstreamResponse("text/event-stream", upstream), request); err != nil {
+		t.Fatalf("TransformOpenAIResponseToComplete() error = %v", err)
+	}
+	if !strings.HasPrefix(rec.Body.String(), "event: ping\n") {
+		t.Errorf("stream does not start with a ping: %q", rec.Body)
+	}
+	events := sseData(t, rec.Body.String())
+	var text strings.Builder
+	for _, event := range events {
+		text.WriteString(toString(event["completion"]))
+	}
+	if text.String() != "Hi there" {
+		t.Errorf("completion = %q, want %q", text.String(), "Hi there")
+	}
+	last := events[len(events)-1]
+	if last["stop_reason"] != "stop_sequence" || last["stop"] != humanStop {
+		t.Errorf("last event = %v, want stop_sequence at %q", last, humanStop)
+	}
+}
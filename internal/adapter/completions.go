@@ -0,0 +1,185 @@
+package adapter
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ilcm96/gh-copilot-proxy/internal/httpx"
+)
+
+// ConvertRequestCompletionsToOpenAI wraps an OpenAI legacy completions prompt into a chat/completions request.
+// ConvertRequestCompletionsToOpenAI 는 OpenAI 레거시 completions 프롬프트를 chat/completions 요청으로 감쌉니다.
+func ConvertRequestCompletionsToOpenAI(body map[string]any) (map[string]any, error) {
+	prompt, err := completionsPrompt(body["prompt"])
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]any{
+		"model":    body["model"],
+		"messages": []any{map[string]any{"role": "user", "content": prompt}},
+	}
+	if stream, _ := body["stream"].(bool); stream {
+		result["stream"] = true
+		result["stream_options"] = map[string]any{"include_usage": true}
+	}
+	for _, key := range []string{"max_tokens", "temperature", "top_p", "presence_penalty", "frequency_penalty", "seed", "user"} {
+		if value, ok := body[key]; ok && value != nil {
+			result[key] = value
+		}
+	}
+	if stops := stopSequences(body["stop"]); len(stops) > 0 {
+		result["stop"] = upstreamStops(stops)
+	}
+	return result, nil
+}
+
+// completionsPrompt reads a prompt given as a string or a one-element list of strings.
+// completionsPrompt 는 문자열이나 원소가 하나인 문자열 목록으로 주어진 프롬프트를 읽습니다.
+func completionsPrompt(v any) (string, error) {
+	switch prompt := v.(type) {
+	case string:
+		return prompt, nil
+	case []any:
+		if len(prompt) == 1 {
+			if text, ok := prompt[0].(string); ok {
+				return text, nil
+			}
+		}
+		return "", errors.New("prompt must be a string or a list with a single string")
+	case nil:
+		return "", errors.New("prompt is required")
+	}
+	return "", errors.New("prompt must be a string")
+}
+
+// TransformOpenAIResponseToCompletions writes a Copilot chat/completions response as an OpenAI legacy completion.
+// TransformOpenAIResponseToCompletions 는 Copilot chat/completions 응답을 OpenAI 레거시 completion 으로 작성합니다.
+func TransformOpenAIResponseToCompletions(w http.ResponseWriter, resp *http.Response, request map[string]any) error {
+	httpx.CopyHeaders(w.Header(), resp.Header)
+	w.Header().Del("Content-Length")
+
+	id := "cmpl-" + randomHex()
+	created := time.Now().Unix()
+	stops := &stopScanner{stops: stopSequences(request["stop"])}
+	echo := ""
+	if enabled, _ := request["echo"].(bool); enabled {
+		echo, _ = completionsPrompt(request["prompt"])
+	}
+
+	contentType := strings.ToLower(resp.Header.Get("Content-Type"))
+	if !strings.Contains(contentType, "text/event-stream") {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		var payload map[string]any
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return err
+		}
+		text, finishReason := chatText(payload)
+		text = stops.feed(text) + stops.flush()
+		result := completionObject(id, created, payload["model"], echo+text, completionsFinishReason(finishReason, stops))
+		if usage, ok := payload["usage"].(map[string]any); ok {
+			result["usage"] = usage
+		}
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(resp.StatusCode)
+		_, err = w.Write(data)
+		return err
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(resp.StatusCode)
+	flusher, _ := w.(http.Flusher)
+	send := func(payload map[string]any) error {
+		data, err := marshalEventPayload(payload)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write([]byte("data: " + string(data) + "\n\n")); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+
+	var model any
+	var usage map[string]any
+	finishReason := ""
+	if echo != "" {
+		if err := send(completionObject(id, created, request["model"], echo, nil)); err != nil {
+			return err
+		}
+	}
+	err := eachChatChunk(resp.Body, func(chunk map[string]any) error {
+		if errPayload, ok := chunk["error"].(map[string]any); ok {
+			return send(map[string]any{"error": errPayload})
+		}
+		if m := toString(chunk["model"]); m != "" {
+			model = m
+		}
+		if u, ok := chunk["usage"].(map[string]any); ok {
+			usage = u
+		}
+		text, reason := chatText(chunk)
+		if reason != "" {
+			finishReason = reason
+		}
+		if text = stops.feed(text); text == "" {
+			return nil
+		}
+		return send(completionObject(id, created, model, text, nil))
+	})
+	if err != nil {
+		return err
+	}
+	final := completionObject(id, created, model, stops.flush(), completionsFinishReason(finishReason, stops))
+	if err := send(final); err != nil {
+		return err
+	}
+	if includeUsage, _ := nestedMapValue(request, "stream_options", "include_usage").(bool); includeUsage && usage != nil {
+		if err := send(map[string]any{"id": id, "object": "text_completion", "created": created, "model": model, "choices": []any{}, "usage": usage}); err != nil {
+			return err
+		}
+	}
+	_, err = w.Write([]byte("data: [DONE]\n\n"))
+	return err
+}
+
+// completionObject returns a legacy completion, or a stream chunk when finishReason is nil.
+// completionObject 는 레거시 completion 을 반환하며, finishReason 이 nil 이면 스트림 청크입니다.
+func completionObject(id string, created int64, model any, text string, finishReason any) map[string]any {
+	return map[string]any{
+		"id":      id,
+		"object":  "text_completion",
+		"created": created,
+		"model":   model,
+		"choices": []any{map[string]any{
+			"text":          text,
+			"index":         0,
+			"logprobs":      nil,
+			"finish_reason": finishReason,
+		}},
+	}
+}
+
+// completionsFinishReason reports "stop" when a stop sequence was hit locally, and the upstream reason otherwise.
+// completionsFinishReason 는 중단 시퀀스를 여기서 만났으면 "stop" 을, 아니면 업스트림 사유를 반환합니다.
+func completionsFinishReason(finishReason string, stops *stopScanner) string {
+	if stops.matched != "" || finishReason == "" {
+		return "stop"
+	}
+	return finishReason
+}
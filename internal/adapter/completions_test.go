@@ -0,0 +1,138 @@
+package adapter
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// sseData returns the JSON payloads of the data lines of an SSE stream, without [DONE].
+func sseData(t *testing.T, stream string) []map[string]any {
+	t.Helper()
+	var payloads []map[string]any
+	for _, line := range strings.Split(stream, "\n") {
+		data, ok := strings.CutPrefix(strings.TrimSpace(line), "data: ")
+		if !ok || data == "[DONE]" {
+			continue
+		}
+		var payload map[string]any
+		if err := json.Unmarshal([]byte(data), &payload); err != nil {
+			t.Fatalf("invalid event %q: %v", data, err)
+		}
+		payloads = append(payloads, payload)
+	}
+	return payloads
+}
+
+func TestConvertRequestCompletionsToOpenAI(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "string prompt",
+			body: `{"model":"gpt-4o","prompt":"Say hi","max_tokens":5,"temperature":0,"echo":true}`,
+			want: `{"model":"gpt-4o","messages":[{"role":"user","content":"Say hi"}],"max_tokens":5,"temperature":0}`,
+		},
+		{
+			name: "single element list prompt with stream",
+			body: `{"model":"gpt-4o","prompt":["Say hi"],"stream":true}`,
+			want: `{"model":"gpt-4o","messages":[{"role":"user","content":"Say hi"}],"stream":true,"stream_options":{"include_usage":true}}`,
+		},
+		{
+			name: "stops are limited to four upstream",
+			body: `{"model":"gpt-4o","prompt":"x","stop":["a","b","c","d","e"]}`,
+			want: `{"model":"gpt-4o","messages":[{"role":"user","content":"x"}],"stop":["a","b","c","d"]}`,
+		},
+		{
+			name:    "several prompts are rejected",
+			body:    `{"model":"gpt-4o","prompt":["a","b"]}`,
+			wantErr: true,
+		},
+		{
+			name:    "token prompts are rejected",
+			body:    `{"model":"gpt-4o","prompt":[1,2,3]}`,
+			wantErr: true,
+		},
+		{
+			name:    "missing prompt is rejected",
+			body:    `{"model":"gpt-4o"}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]any
+			if err := json.Unmarshal([]byte(tt.body), &body); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ConvertRequestCompletionsToOpenAI(body)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ConvertRequestCompletionsToOpenAI() = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ConvertRequestCompletionsToOpenAI() error = %v", err)
+			}
+			assertJSON(t, got, tt.want)
+		})
+	}
+}
+
+func TestTransformOpenAIResponseToCompletions(t *testing.T) {
+	request := map[string]any{"model": "gpt-4o", "prompt": "Count:", "stop": "3"}
+	upstream := `{"id":"c1","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":" 1 2 3 4"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":8,"total_tokens":11}}`
+	rec := httptest.NewRecorder()
+	if err := TransformOpenAIResponseToCompletions(rec, upstreamResponse("application/json", upstream), request); err != nil {
+		t.Fatalf("TransformOpenAIResponseToCompletions() error = %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid body %s: %v", rec.Body, err)
+	}
+	if got["object"] != "text_completion" || !strings.HasPrefix(toString(got["id"]), "cmpl-") {
+		t.Errorf("object = %v, id = %v", got["object"], got["id"])
+	}
+	assertJSON(t, got["choices"], `[{"text":" 1 2 ","index":0,"logprobs":null,"finish_reason":"stop"}]`)
+	assertJSON(t, got["usage"], `{"prompt_tokens":3,"completion_tokens":8,"total_tokens":11}`)
+}
+
+func TestTransformOpenAIResponseToCompletionsStream(t *testing.T) {
+	request := map[string]any{
+		"model":          "gpt-4o",
+		"prompt":         "Say hi",
+		"stream":         true,
+		"stream_options": map[string]any{"include_usage": true},
+	}
+	upstream := "data: {\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hel\"}}]}\n\n" +
+		"data: {\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"lo\"},\"finish_reason\":\"length\"}]}\n\n" +
+		"data: {\"model\":\"gpt-4o\",\"choices\":[],\"usage\":{\"prompt_tokens\":2,\"completion_tokens\":2,\"total_tokens\":4}}\n\n" +
+		"data: [DONE]\n\n"
+	rec := httptest.NewRecorder()
+	if err := TransformOpenAIResponseToCompletions(rec, upstreamResponse("text/event-stream", upstream), request); err != nil {
+		t.Fatalf("TransformOpenAIResponseToCompletions() error = %v", err)
+	}
+	if !strings.HasSuffix(rec.Body.String(), "data: [DONE]\n\n") {
+		t.Errorf("stream does not end with [DONE]: %q", rec.Body)
+	}
+	events := sseData(t, rec.Body.String())
+	if len(events) != 4 {
+		t.Fatalf("got %d events, want 4: %q", len(events), rec.Body)
+	}
+	var text strings.Builder
+	for _, event := range events[:3] {
+		text.WriteString(toString(nestedMapValue(event, "choices", 0, "text")))
+	}
+	if text.String() != "Hello" {
+		t.Errorf("text = %q, want %q", text.String(), "Hello")
+	}
+	if reason := nestedMapValue(events[2], "choices", 0, "finish_reason"); reason != "length" {
+		t.Errorf("finish_reason = %v, want length", reason)
+	}
+	assertJSON(t, events[3]["usage"], `{"prompt_tokens":2,"completion_tokens":2,"total_tokens":4}`)
+}
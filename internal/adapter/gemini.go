@@ -0,0 +1,397 @@
+package adapter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var geminiFinishReasonMap = map[string]string{
+	"stop":           "STOP",
+	"tool_calls":     "STOP",
+	"function_call":  "STOP",
+	"length":         "MAX_TOKENS",
+	"content_filter": "SAFETY",
+}
+
+// ConvertRequestGeminiToOpenAI converts a Gemini generateContent request for model into a chat/completions request.
+// ConvertRequestGeminiToOpenAI 는 model 에 대한 Gemini generateContent 요청을 chat/completions 요청으로 변환합니다.
+func ConvertRequestGeminiToOpenAI(model string, body map[string]any, stream bool) (map[string]any, error) {
+	var messages []any
+	if system, ok := body["systemInstruction"].(map[string]any); ok {
+		if text := geminiText(system["parts"]); text != "" {
+			messages = append(messages, map[string]any{"role": "system", "content": text})
+		}
+	}
+
+	// Gemini matches function responses to calls by name when the call carries no id,
+	// so synthesized ids are queued per name in call order.
+	// Gemini 는 id 가 없는 호출을 이름으로 응답과 짝지으므로, 만든 id 를 이름별로 호출 순서대로 쌓아 둡니다.
+	pending := make(map[string][]string)
+	for _, raw := range toSlice(body["contents"]) {
+		content, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		parts := toSlice(content["parts"])
+		if toString(content["role"]) == "model" {
+			if message := geminiModelMessage(parts, pending); message != nil {
+				messages = append(messages, message)
+			}
+			continue
+		}
+		var userParts []any
+		for _, rawPart := range parts {
+			part, ok := rawPart.(map[string]any)
+			if !ok {
+				continue
+			}
+			if response, ok := part["functionResponse"].(map[string]any); ok {
+				name := toString(response["name"])
+				id := toString(response["id"])
+				if queue := pending[name]; id == "" && len(queue) > 0 {
+					id, pending[name] = queue[0], queue[1:]
+				}
+				messages = append(messages, map[string]any{
+					"role":         "tool",
+					"tool_call_id": id,
+					"content":      stringifyJSON(response["response"]),
+				})
+				continue
+			}
+			converted, err := geminiUserPart(part)
+			if err != nil {
+				return nil, err
+			}
+			if converted != nil {
+				userParts = append(userParts, converted)
+			}
+		}
+		if len(userParts) == 0 {
+			continue
+		}
+		if text, ok := geminiTextOnly(userParts); ok {
+			messages = append(messages, map[string]any{"role": "user", "content": text})
+		} else {
+			messages = append(messages, map[string]any{"role": "user", "content": userParts})
+		}
+	}
+	if len(messages) == 0 {
+		return nil, errors.New("contents is required")
+	}
+
+	result := map[string]any{
+		"model":    model,
+		"messages": messages,
+	}
+	if stream {
+		result["stream"] = true
+		result["stream_options"] = map[string]any{"include_usage": true}
+	}
+
+	var tools []map[string]any
+	for _, raw := range toSlice(body["tools"]) {
+		for _, rawDecl := range toSlice(nestedMapValue(raw, "functionDeclarations")) {
+			decl, ok := rawDecl.(map[string]any)
+			if !ok || toString(decl["name"]) == "" {
+				continue
+			}
+			parameters := decl["parametersJsonSchema"]
+			if parameters == nil {
+				parameters = geminiSchema(decl["parameters"])
+			}
+			if parameters == nil {
+				parameters = map[string]any{"type": "object", "properties": map[string]any{}}
+			}
+			tools = append(tools, map[string]any{
+				"type": "function",
+				"function": map[string]any{
+					"name":        toString(decl["name"]),
+					"description": toString(decl["description"]),
+					"parameters":  parameters,
+				},
+			})
+		}
+	}
+	if len(tools) > 0 {
+		result["tools"] = tools
+	}
+	if config, ok := nestedMapValue(body, "toolConfig", "functionCallingConfig").(map[string]any); ok {
+		switch strings.ToUpper(toString(config["mode"])) {
+		case "AUTO":
+			result["tool_choice"] = "auto"
+		case "NONE":
+			result["tool_choice"] = "none"
+		case "ANY", "VALIDATED":
+			if allowed := toSlice(config["allowedFunctionNames"]); len(allowed) == 1 {
+				result["tool_choice"] = map[string]any{
+					"type":     "function",
+					"function": map[string]any{"name": toString(allowed[0])},
+				}
+			} else {
+				result["tool_choice"] = "required"
+			}
+		}
+	}
+
+	config, _ := body["generationConfig"].(map[string]any)
+	for from, to := range map[string]string{
+		"temperature":      "temperature",
+		"topP":             "top_p",
+		"maxOutputTokens":  "max_tokens",
+		"presencePenalty":  "presence_penalty",
+		"frequencyPenalty": "frequency_penalty",
+		"seed":             "seed",
+	} {
+		if value, ok := config[from]; ok && value != nil {
+			result[to] = value
+		}
+	}
+	if stops := stopSequences(config["stopSequences"]); len(stops) > 0 {
+		result["stop"] = upstreamStops(stops)
+	}
+	if toString(config["responseMimeType"]) == "application/json" {
+		schema := config["responseJsonSchema"]
+		if schema == nil {
+			schema = geminiSchema(config["responseSchema"])
+		}
+		if schema != nil {
+			result["response_format"] = map[string]any{
+				"type":        "json_schema",
+				"json_schema": map[string]any{"name": "response", "schema": schema},
+			}
+		} else {
+			result["response_format"] = map[string]any{"type": "json_object"}
+		}
+	}
+	return result, nil
+}
+
+// geminiModelMessage converts the parts of a model turn into an assistant message, queueing ids for its function calls.
+// geminiModelMessage 는 model 차례의 파트를 assistant 메시지로 변환하며, 함수 호출 id 를 대기열에 넣습니다.
+func geminiModelMessage(parts []any, pending map[string][]string) map[string]any {
+	var texts []string
+	var calls []any
+	for _, raw := range parts {
+		part, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		if thought, _ := part["thought"].(bool); thought {
+			continue
+		}
+		if call, ok := part["functionCall"].(map[string]any); ok {
+			name := toString(call["name"])
+			id := toString(call["id"])
+			if id == "" {
+				id = newItemID("call")
+				pending[name] = append(pending[name], id)
+			}
+			args := call["args"]
+			if args == nil {
+				args = map[string]any{}
+			}
+			calls = append(calls, map[string]any{
+				"id":   id,
+				"type": "function",
+				"function": map[string]any{
+					"name":      name,
+					"arguments": stringifyJSON(args),
+				},
+			})
+			continue
+		}
+		if text := toString(part["text"]); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	if len(texts) == 0 && len(calls) == 0 {
+		return nil
+	}
+	message := map[string]any{"role": "assistant", "content": nil}
+	if len(texts) > 0 {
+		message["content"] = strings.Join(texts, "")
+	}
+	if len(calls) > 0 {
+		message["tool_calls"] = calls
+	}
+	return message
+}
+
+// geminiUserPart converts a text, inlineData or fileData part into a chat content part.
+// geminiUserPart 는 text, inlineData, fileData 파트를 chat 콘텐츠 파트로 변환합니다.
+//
+// Copilot chat only accepts images, so inline and file data of any other type is rejected.
+// Copilot chat 은 이미지만 받으므로 다른 유형의 인라인, 파일 데이터는 거부합니다.
+func geminiUserPart(part map[string]any) (map[string]any, error) {
+	if text, ok := part["text"].(string); ok {
+		return map[string]any{"type": "text", "text": text}, nil
+	}
+	if data, ok := part["inlineData"].(map[string]any); ok {
+		mimeType := toString(data["mimeType"])
+		if !strings.HasPrefix(mimeType, "image/") {
+			return nil, fmt.Errorf("inlineData with mimeType %q is not supported; only image/* is accepted", mimeType)
+		}
+		url := "data:" + mimeType + ";base64," + toString(data["data"])
+		return map[string]any{"type": "image_url", "image_url": map[string]any{"url": url}}, nil
+	}
+	if data, ok := part["fileData"].(map[string]any); ok && toString(data["fileUri"]) != "" {
+		mimeType := toString(data["mimeType"])
+		if !strings.HasPrefix(mimeType, "image/") {
+			return nil, fmt.Errorf("fileData with mimeType %q is not supported; only image/* is accepted", mimeType)
+		}
+		return map[string]any{"type": "image_url", "image_url": map[string]any{"url": toString(data["fileUri"])}}, nil
+	}
+	return nil, nil
+}
+
+// geminiTextOnly joins chat content parts into one string when they are all text.
+// geminiTextOnly 는 chat 콘텐츠 파트가 모두 텍스트이면 하나의 문자열로 합칩니다.
+func geminiTextOnly(parts []any) (string, bool) {
+	var texts []string
+	for _, raw := range parts {
+		part, _ := raw.(map[string]any)
+		if toString(part["type"]) != "text" {
+			return "", false
+		}
+		texts = append(texts, toString(part["text"]))
+	}
+	return strings.Join(texts, "\n"), true
+}
+
+// geminiText joins the text of Gemini parts, such as those of a systemInstruction.
+// geminiText 는 systemInstruction 같은 Gemini 파트의 텍스트를 합칩니다.
+func geminiText(parts any) string {
+	var texts []string
+	for _, raw := range toSlice(parts) {
+		if text := toString(nestedMapValue(raw, "text")); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// geminiSchema converts a Gemini OpenAPI-style schema into JSON Schema, lowercasing types and expanding nullable.
+// geminiSchema 는 Gemini 의 OpenAPI 형식 스키마를 JSON Schema 로 변환하며, 타입을 소문자로 바꾸고 nullable 을 풀어 씁니다.
+func geminiSchema(v any) any {
+	switch schema := v.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(schema))
+		for key, value := range schema {
+			switch key {
+			case "type":
+				result[key] = strings.ToLower(toString(value))
+			case "propertyOrdering", "nullable":
+			default:
+				result[key] = geminiSchema(value)
+			}
+		}
+		if nullable, _ := schema["nullable"].(bool); nullable {
+			if typ, ok := result["type"].(string); ok {
+				result["type"] = []any{typ, "null"}
+			}
+		}
+		return result
+	case []any:
+		result := make([]any, len(schema))
+		for i, value := range schema {
+			result[i] = geminiSchema(value)
+		}
+		return result
+	}
+	return v
+}
+
+// ConvertResponseOpenAIToGemini transforms a Copilot chat/completions response into a Gemini GenerateContentResponse.
+// ConvertResponseOpenAIToGemini 는 Copilot chat/completions 응답을 Gemini GenerateContentResponse 로 변환합니다.
+func ConvertResponseOpenAIToGemini(body map[string]any, model, id string) (map[string]any, error) {
+	choices := toSlice(body["choices"])
+	if len(choices) == 0 {
+		return nil, errors.New("no choices in response")
+	}
+	choice, ok := choices[0].(map[string]any)
+	if !ok {
+		return nil, errors.New("invalid choice format")
+	}
+
+	parts := []any{}
+	if message, ok := choice["message"].(map[string]any); ok {
+		if text := toString(message["content"]); text != "" {
+			parts = append(parts, map[string]any{"text": text})
+		}
+		for _, raw := range toSlice(message["tool_calls"]) {
+			call, ok := raw.(map[string]any)
+			if !ok {
+				continue
+			}
+			parts = append(parts, geminiFunctionCallPart(toString(call["id"]), toString(nestedMapValue(call, "function", "name")), toString(nestedMapValue(call, "function", "arguments"))))
+		}
+	}
+	if m := toString(body["model"]); m != "" {
+		model = m
+	}
+	return geminiResponse(id, model, parts, toString(choice["finish_reason"]), body["usage"]), nil
+}
+
+// geminiFunctionCallPart returns a functionCall part, decoding the JSON arguments into args.
+// geminiFunctionCallPart 는 JSON 인자를 args 로 풀어 functionCall 파트를 반환합니다.
+func geminiFunctionCallPart(id, name, arguments string) map[string]any {
+	args := map[string]any{}
+	if arguments != "" {
+		_ = json.Unmarshal([]byte(arguments), &args)
+	}
+	call := map[string]any{"name": name, "args": args}
+	if id != "" {
+		call["id"] = id
+	}
+	return map[string]any{"functionCall": call}
+}
+
+// geminiResponse returns a GenerateContentResponse holding one candidate; finishReason and usage are omitted when empty.
+// geminiResponse 는 후보 하나를 담은 GenerateContentResponse 를 반환하며, finishReason 과 usage 가 비어 있으면 생략합니다.
+func geminiResponse(id, model string, parts []any, finishReason string, usage any) map[string]any {
+	candidate := map[string]any{
+		"content": map[string]any{"role": "model", "parts": parts},
+		"index":   0,
+	}
+	if finishReason != "" {
+		reason, ok := geminiFinishReasonMap[finishReason]
+		if !ok {
+			reason = "OTHER"
+		}
+		candidate["finishReason"] = reason
+	}
+	result := map[string]any{
+		"candidates":   []any{candidate},
+		"modelVersion": model,
+		"responseId":   id,
+	}
+	if usage, ok := usage.(map[string]any); ok {
+		result["usageMetadata"] = geminiUsage(usage)
+	}
+	return result
+}
+
+// geminiUsage converts chat/completions usage into Gemini usageMetadata.
+// geminiUsage 는 chat/completions 사용량을 Gemini usageMetadata 로 변환합니다.
+func geminiUsage(usage map[string]any) map[string]any {
+	input := toInt(usage["prompt_tokens"])
+	output := toInt(usage["completion_tokens"])
+	total := toInt(usage["total_tokens"])
+	if total == 0 {
+		total = input + output
+	}
+	result := map[string]any{
+		"promptTokenCount":     input,
+		"candidatesTokenCount": output,
+		"totalTokenCount":      total,
+	}
+	if cached := toInt(nestedMapValue(usage, "prompt_tokens_details", "cached_tokens")); cached > 0 {
+		result["cachedContentTokenCount"] = cached
+	}
+	if reasoning := toInt(nestedMapValue(usage, "completion_tokens_details", "reasoning_tokens")); reasoning > 0 {
+		result["thoughtsTokenCount"] = reasoning
+	}
+	return result
+}
@@ -0,0 +1,195 @@
+package adapter
+
+import (
+	"encoding/json"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/ilcm96/gh-copilot-proxy/internal/httpx"
+)
+
+// geminiStream writes streamGenerateContent chunks, as SSE events when sse is set and as a JSON array otherwise.
+// geminiStream 은 streamGenerateContent 청크를 작성하며, sse 가 켜져 있으면 SSE 이벤트로, 아니면 JSON 배열로 씁니다.
+type geminiStream struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	sse     bool
+	written int
+}
+
+// geminiStreamCall accumulates one streamed tool call until the stream ends.
+// geminiStreamCall 은 스트림이 끝날 때까지 스트리밍된 도구 호출 하나를 모읍니다.
+type geminiStreamCall struct {
+	id        string
+	name      string
+	arguments strings.Builder
+}
+
+// TransformOpenAIResponseToGemini writes a Copilot chat/completions response as a Gemini generateContent response.
+// TransformOpenAIResponseToGemini 는 Copilot chat/completions 응답을 Gemini generateContent 응답으로 작성합니다.
+//
+// Streaming requests get one chunk per text delta; function calls, the finish reason and usage arrive in the last chunk.
+// sse only applies to streaming requests, so generateContent always answers with a JSON object.
+// 스트리밍 요청은 텍스트 조각마다 청크를 받으며, 함수 호출과 종료 사유, 사용량은 마지막 청크에 담깁니다.
+// sse 는 스트리밍 요청에만 적용되므로 generateContent 는 항상 JSON 객체로 응답합니다.
+func TransformOpenAIResponseToGemini(w http.ResponseWriter, resp *http.Response, model string, stream, sse bool) error {
+	httpx.CopyHeaders(w.Header(), resp.Header)
+	w.Header().Del("Content-Length")
+
+	id := randomHex()
+	out := &geminiStream{w: w, sse: stream && sse}
+	out.flusher, _ = w.(http.Flusher)
+	if out.sse {
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("Content-Type", "text/event-stream")
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+
+	contentType := strings.ToLower(resp.Header.Get("Content-Type"))
+	if !strings.Contains(contentType, "text/event-stream") {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		var payload map[string]any
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return err
+		}
+		converted, err := ConvertResponseOpenAIToGemini(payload, model, id)
+		if err != nil {
+			return err
+		}
+		w.WriteHeader(resp.StatusCode)
+		if !stream {
+			data, err := json.Marshal(converted)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(data)
+			return err
+		}
+		if err := out.send(converted); err != nil {
+			return err
+		}
+		return out.close()
+	}
+
+	w.WriteHeader(resp.StatusCode)
+	calls := make(map[int]*geminiStreamCall)
+	var usage any
+	finishReason := ""
+	failed := false
+	err := eachChatChunk(resp.Body, func(chunk map[string]any) error {
+		if failed {
+			return nil
+		}
+		if errPayload, ok := chunk["error"].(map[string]any); ok {
+			failed = true
+			message := toString(errPayload["message"])
+			if message == "" {
+				message = stringifyJSON(errPayload)
+			}
+			return out.send(map[string]any{
+				"error": map[string]any{"code": http.StatusInternalServerError, "message": message, "status": "INTERNAL"},
+			})
+		}
+		if m := toString(chunk["model"]); m != "" {
+			model = m
+		}
+		if u, ok := chunk["usage"].(map[string]any); ok {
+			usage = u
+		}
+		choice, _ := nestedMapValue(chunk, "choices", 0).(map[string]any)
+		if reason := toString(choice["finish_reason"]); reason != "" {
+			finishReason = reason
+		}
+		for _, raw := range toSlice(nestedMapValue(choice, "delta", "tool_calls")) {
+			delta, ok := raw.(map[string]any)
+			if !ok {
+				continue
+			}
+			index := toInt(delta["index"])
+			call, ok := calls[index]
+			if !ok {
+				call = &geminiStreamCall{}
+				calls[index] = call
+			}
+			if id := toString(delta["id"]); id != "" {
+				call.id = id
+			}
+			if name := toString(nestedMapValue(delta, "function", "name")); name != "" {
+				call.name = name
+			}
+			call.arguments.WriteString(toString(nestedMapValue(delta, "function", "arguments")))
+		}
+		text, _ := chatText(chunk)
+		if text == "" {
+			return nil
+		}
+		return out.send(geminiResponse(id, model, []any{map[string]any{"text": text}}, "", nil))
+	})
+	if err != nil {
+		return err
+	}
+	if !failed {
+		parts := []any{}
+		for _, index := range slices.Sorted(maps.Keys(calls)) {
+			call := calls[index]
+			parts = append(parts, geminiFunctionCallPart(call.id, call.name, call.arguments.String()))
+		}
+		if len(parts) == 0 {
+			parts = append(parts, map[string]any{"text": ""})
+		}
+		if finishReason == "" {
+			finishReason = "stop"
+		}
+		if err := out.send(geminiResponse(id, model, parts, finishReason, usage)); err != nil {
+			return err
+		}
+	}
+	return out.close()
+}
+
+// send writes one chunk, opening the JSON array before the first one.
+// send 는 청크 하나를 작성하며, 첫 청크 앞에서 JSON 배열을 엽니다.
+func (s *geminiStream) send(payload map[string]any) error {
+	data, err := marshalEventPayload(payload)
+	if err != nil {
+		return err
+	}
+	switch {
+	case s.sse:
+		data = append(append([]byte("data: "), data...), "\r\n\r\n"...)
+	case s.written == 0:
+		data = append([]byte("["), data...)
+	default:
+		data = append([]byte(",\r\n"), data...)
+	}
+	if _, err := s.w.Write(data); err != nil {
+		return err
+	}
+	s.written++
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+	return nil
+}
+
+// close ends the JSON array; SSE streams need no terminator.
+// close 는 JSON 배열을 닫으며, SSE 스트림에는 종료 표시가 필요 없습니다.
+func (s *geminiStream) close() error {
+	if s.sse {
+		return nil
+	}
+	end := "]"
+	if s.written == 0 {
+		end = "[]"
+	}
+	_, err := s.w.Write([]byte(end))
+	return err
+}
@@ -0,0 +1,229 @@
+package adapter
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConvertRequestGeminiToOpenAI(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		stream  bool
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "text turns with system instruction and config",
+			body: `{
+				"systemInstruction": {"parts":[{"text":"Be brief."}]},
+				"contents": [
+					{"role":"user","parts":[{"text":"Hi"}]},
+					{"role":"model","parts":[{"text":"Hello"},{"text":"!","thought":false}]},
+					{"role":"user","parts":[{"text":"Bye"}]}
+				],
+				"generationConfig": {"temperature":0.2,"maxOutputTokens":64,"stopSequences":["END"]}
+			}`,
+			want: `{
+				"model": "gemini-2.5-pro",
+				"messages": [
+					{"role":"system","content":"Be brief."},
+					{"role":"user","content":"Hi"},
+					{"role":"assistant","content":"Hello!"},
+					{"role":"user","content":"Bye"}
+				],
+				"temperature": 0.2,
+				"max_tokens": 64,
+				"stop": ["END"]
+			}`,
+		},
+		{
+			name:   "stream asks for usage",
+			body:   `{"contents":[{"parts":[{"text":"Hi"}]}]}`,
+			stream: true,
+			want:   `{"model":"gemini-2.5-pro","messages":[{"role":"user","content":"Hi"}],"stream":true,"stream_options":{"include_usage":true}}`,
+		},
+		{
+			name: "function calls without ids are paired by name in order",
+			body: `{
+				"contents": [
+					{"role":"user","parts":[{"text":"Weather?"}]},
+					{"role":"model","parts":[{"functionCall":{"name":"get_weather","args":{"city":"Seoul"}}}]},
+					{"role":"user","parts":[{"functionResponse":{"name":"get_weather","response":{"temp":20}}}]}
+				],
+				"tools": [{"functionDeclarations":[{"name":"get_weather","description":"Weather","parameters":{"type":"OBJECT","properties":{"city":{"type":"STRING","nullable":true}}}}]}],
+				"toolConfig": {"functionCallingConfig":{"mode":"ANY","allowedFunctionNames":["get_weather"]}}
+			}`,
+			want: `{
+				"model": "gemini-2.5-pro",
+				"messages": [
+					{"role":"user","content":"Weather?"},
+					{"role":"assistant","content":null,"tool_calls":[{"id":"CALL_ID","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Seoul\"}"}}]},
+					{"role":"tool","tool_call_id":"CALL_ID","content":"{\"temp\":20}"}
+				],
+				"tools": [{"type":"function","function":{"name":"get_weather","description":"Weather","parameters":{"type":"object","properties":{"city":{"type":["string","null"]}}}}}],
+				"tool_choice": {"type":"function","function":{"name":"get_weather"}}
+			}`,
+		},
+		{
+			name: "inline image becomes an image part",
+			body: `{"contents":[{"role":"user","parts":[{"text":"What is this?"},{"inlineData":{"mimeType":"image/png","data":"AA"}}]}]}`,
+			want: `{"model":"gemini-2.5-pro","messages":[{"role":"user","content":[{"type":"text","text":"What is this?"},{"type":"image_url","image_url":{"url":"data:image/png;base64,AA"}}]}]}`,
+		},
+		{
+			name: "json response schema",
+			body: `{"contents":[{"parts":[{"text":"Hi"}]}],"generationConfig":{"responseMimeType":"application/json","responseSchema":{"type":"OBJECT"}}}`,
+			want: `{"model":"gemini-2.5-pro","messages":[{"role":"user","content":"Hi"}],"response_format":{"type":"json_schema","json_schema":{"name":"response","schema":{"type":"object"}}}}`,
+		},
+		{
+			name:    "inline pdf is rejected",
+			body:    `{"contents":[{"parts":[{"inlineData":{"mimeType":"application/pdf","data":"AA"}}]}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "file audio is rejected",
+			body:    `{"contents":[{"parts":[{"fileData":{"mimeType":"audio/mpeg","fileUri":"gs://bucket/a.mp3"}}]}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "empty contents are rejected",
+			body:    `{"contents":[]}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]any
+			if err := json.Unmarshal([]byte(tt.body), &body); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ConvertRequestGeminiToOpenAI("gemini-2.5-pro", body, tt.stream)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ConvertRequestGeminiToOpenAI() = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ConvertRequestGeminiToOpenAI() error = %v", err)
+			}
+			want := tt.want
+			if id := toString(nestedMapValue(got, "messages", 1, "tool_calls", 0, "id")); id != "" {
+				want = strings.ReplaceAll(want, "CALL_ID", id)
+			}
+			assertJSON(t, got, want)
+		})
+	}
+}
+
+func TestConvertResponseOpenAIToGemini(t *testing.T) {
+	var body map[string]any
+	err := json.Unmarshal([]byte(`{
+		"model": "gpt-4o",
+		"choices": [{"message":{"content":"Checking.","tool_calls":[{"id":"call_1","function":{"name":"get_weather","arguments":"{\"city\":\"Seoul\"}"}}]},"finish_reason":"tool_calls"}],
+		"usage": {"prompt_tokens":5,"completion_tokens":3,"total_tokens":8,"prompt_tokens_details":{"cached_tokens":2}}
+	}`), &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ConvertResponseOpenAIToGemini(body, "gemini-2.5-pro", "r1")
+	if err != nil {
+		t.Fatalf("ConvertResponseOpenAIToGemini() error = %v", err)
+	}
+	assertJSON(t, got, `{
+		"candidates": [{
+			"content": {"role":"model","parts":[{"text":"Checking."},{"functionCall":{"id":"call_1","name":"get_weather","args":{"city":"Seoul"}}}]},
+			"index": 0,
+			"finishReason": "STOP"
+		}],
+		"modelVersion": "gpt-4o",
+		"responseId": "r1",
+		"usageMetadata": {"promptTokenCount":5,"candidatesTokenCount":3,"totalTokenCount":8,"cachedContentTokenCount":2}
+	}`)
+}
+
+func TestTransformOpenAIResponseToGemini(t *testing.T) {
+	jsonBody := `{"model":"gpt-4o","choices":[{"message":{"content":"Hello"},"finish_reason":"length"}]}`
+	streamBody := "data: {\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hel\"}}]}\n\n" +
+		"data: {\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"lo\"},\"finish_reason\":\"stop\"}]}\n\n" +
+		"data: [DONE]\n\n"
+	tests := []struct {
+		name            string
+		upstreamType    string
+		upstreamBody    string
+		stream, sse     bool
+		wantContentType string
+		wantPrefix      string
+	}{
+		{
+			name:         "generateContent answers with an object",
+			upstreamType: "application/json", upstreamBody: jsonBody,
+			wantContentType: "application/json", wantPrefix: "{",
+		},
+		{
+			name:         "generateContent ignores alt=sse",
+			upstreamType: "application/json", upstreamBody: jsonBody, sse: true,
+			wantContentType: "application/json", wantPrefix: "{",
+		},
+		{
+			name:         "streamGenerateContent answers with an array",
+			upstreamType: "text/event-stream", upstreamBody: streamBody, stream: true,
+			wantContentType: "application/json", wantPrefix: "[",
+		},
+		{
+			name:         "streamGenerateContent with alt=sse answers with events",
+			upstreamType: "text/event-stream", upstreamBody: streamBody, stream: true, sse: true,
+			wantContentType: "text/event-stream", wantPrefix: "data: ",
+		},
+		{
+			name:         "streamGenerateContent over a JSON upstream still streams",
+			upstreamType: "application/json", upstreamBody: jsonBody, stream: true, sse: true,
+			wantContentType: "text/event-stream", wantPrefix: "data: ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			err := TransformOpenAIResponseToGemini(rec, upstreamResponse(tt.upstreamType, tt.upstreamBody), "gemini-2.5-pro", tt.stream, tt.sse)
+			if err != nil {
+				t.Fatalf("TransformOpenAIResponseToGemini() error = %v", err)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			body := rec.Body.String()
+			if !strings.HasPrefix(body, tt.wantPrefix) {
+				t.Fatalf("body = %q, want prefix %q", body, tt.wantPrefix)
+			}
+
+			var chunks []map[string]any
+			switch {
+			case tt.sse && tt.stream:
+				chunks = sseData(t, body)
+			case tt.stream:
+				if err := json.Unmarshal([]byte(body), &chunks); err != nil {
+					t.Fatalf("invalid array %q: %v", body, err)
+				}
+			default:
+				var chunk map[string]any
+				if err := json.Unmarshal([]byte(body), &chunk); err != nil {
+					t.Fatalf("invalid object %q: %v", body, err)
+				}
+				chunks = append(chunks, chunk)
+			}
+			var text strings.Builder
+			for _, chunk := range chunks {
+				text.WriteString(toString(nestedMapValue(chunk, "candidates", 0, "content", "parts", 0, "text")))
+			}
+			if text.String() != "Hello" {
+				t.Errorf("text = %q, want Hello", text.String())
+			}
+			last := chunks[len(chunks)-1]
+			if reason := nestedMapValue(last, "candidates", 0, "finishReason"); reason == nil {
+				t.Errorf("last chunk %v has no finishReason", last)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package adapter
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rateLimitHeaders pairs each OpenAI rate limit header with its Anthropic counterpart.
+// rateLimitHeaders 는 OpenAI 요청 한도 헤더와 대응하는 Anthropic 헤더를 짝지어 둡니다.
+var rateLimitHeaders = []struct {
+	openAI    string
+	anthropic string
+	reset     bool
+}{
+	{"X-Ratelimit-Limit-Requests", "Anthropic-Ratelimit-Requests-Limit", false},
+	{"X-Ratelimit-Remaining-Requests", "Anthropic-Ratelimit-Requests-Remaining", false},
+	{"X-Ratelimit-Reset-Requests", "Anthropic-Ratelimit-Requests-Reset", true},
+	{"X-Ratelimit-Limit-Tokens", "Anthropic-Ratelimit-Tokens-Limit", false},
+	{"X-Ratelimit-Remaining-Tokens", "Anthropic-Ratelimit-Tokens-Remaining", false},
+	{"X-Ratelimit-Reset-Tokens", "Anthropic-Ratelimit-Tokens-Reset", true},
+}
+
+// CopyAnthropicHeaders copies only the upstream headers that make sense to an Anthropic client, translating OpenAI rate limit headers.
+// CopyAnthropicHeaders 는 Anthropic 클라이언트에 의미 있는 업스트림 헤더만 복사하며, OpenAI 요청 한도 헤더는 변환합니다.
+//
+// Resets become RFC 3339 timestamps, and a 429 without Retry-After gets one derived from the latest reset.
+// 초기화 시각은 RFC 3339 시각으로 바꾸며, Retry-After 가 없는 429 에는 가장 늦은 초기화 시각으로 값을 채웁니다.
+func CopyAnthropicHeaders(dst, src http.Header, status int, now time.Time) {
+	var latestReset time.Duration
+	for _, h := range rateLimitHeaders {
+		value := strings.TrimSpace(src.Get(h.openAI))
+		if value == "" {
+			continue
+		}
+		if !h.reset {
+			dst.Set(h.anthropic, value)
+			continue
+		}
+		wait, ok := parseReset(value, now)
+		if !ok {
+			continue
+		}
+		latestReset = max(latestReset, wait)
+		dst.Set(h.anthropic, now.Add(wait).UTC().Format(time.RFC3339))
+	}
+
+	if id := src.Get("X-Request-Id"); id != "" {
+		dst.Set("Request-Id", id)
+	}
+	if retry := strings.TrimSpace(src.Get("Retry-After")); retry != "" {
+		dst.Set("Retry-After", retry)
+	} else if status == http.StatusTooManyRequests && latestReset > 0 {
+		dst.Set("Retry-After", strconv.Itoa(int(latestReset.Round(time.Second).Seconds())))
+	}
+}
+
+// parseReset reads an OpenAI reset value, either a duration such as "6m0s" or seconds, as the time left until the reset.
+// parseReset 는 "6m0s" 같은 기간이나 초 단위 숫자로 된 OpenAI 초기화 값을 초기화까지 남은 시간으로 읽습니다.
+func parseReset(value string, now time.Time) (time.Duration, bool) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return max(d, 0), true
+	}
+	secs, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false
+	}
+	// Large values are Unix timestamps rather than relative seconds.
+	if secs > 1e9 {
+		return max(time.Unix(int64(secs), 0).Sub(now), 0), true
+	}
+	return time.Duration(secs * float64(time.Second)), true
+}
@@ -0,0 +1,347 @@
+package adapter
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NewResponseID returns a fresh Responses API response id.
+// NewResponseID 는 새 Responses API 응답 id 를 반환합니다.
+func NewResponseID() string {
+	return newItemID("resp")
+}
+
+// newItemID returns a random id with the given prefix, in the style of OpenAI object ids.
+// newItemID 는 OpenAI 객체 id 형식으로 접두사가 붙은 임의의 id 를 반환합니다.
+func newItemID(prefix string) string {
+	return prefix + "_" + randomHex()
+}
+
+// randomHex returns 32 random hex characters.
+// randomHex 는 임의의 16진수 32자를 반환합니다.
+func randomHex() string {
+	return strings.ReplaceAll(uuid.NewString(), "-", "")
+}
+
+// ResponsesInputToMessages converts Responses API input or output items into chat/completions messages.
+// ResponsesInputToMessages 는 Responses API 입력 또는 출력 항목을 chat/completions 메시지로 변환합니다.
+func ResponsesInputToMessages(input any) []any {
+	switch v := input.(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []any{map[string]any{"role": "user", "content": v}}
+	case []any:
+		var messages []any
+		for _, raw := range v {
+			item, ok := raw.(map[string]any)
+			if !ok {
+				continue
+			}
+			switch toString(item["type"]) {
+			case "function_call":
+				call := map[string]any{
+					"id":   toString(item["call_id"]),
+					"type": "function",
+					"function": map[string]any{
+						"name":      toString(item["name"]),
+						"arguments": toString(item["arguments"]),
+					},
+				}
+				if n := len(messages); n > 0 {
+					if last, ok := messages[n-1].(map[string]any); ok && last["role"] == "assistant" {
+						calls, _ := last["tool_calls"].([]any)
+						last["tool_calls"] = append(calls, call)
+						continue
+					}
+				}
+				messages = append(messages, map[string]any{"role": "assistant", "content": nil, "tool_calls": []any{call}})
+			case "function_call_output":
+				output := responsesContent(item["output"])
+				if text, ok := output.(string); ok {
+					messages = append(messages, map[string]any{"role": "tool", "tool_call_id": toString(item["call_id"]), "content": text})
+				} else {
+					messages = append(messages, map[string]any{"role": "tool", "tool_call_id": toString(item["call_id"]), "content": stringifyJSON(item["output"])})
+				}
+			case "", "message":
+				role := toString(item["role"])
+				if role == "developer" {
+					role = "system"
+				}
+				if role != "user" && role != "assistant" && role != "system" {
+					continue
+				}
+				content := responsesContent(item["content"])
+				if content == nil {
+					continue
+				}
+				messages = append(messages, map[string]any{"role": role, "content": content})
+			}
+		}
+		return messages
+	}
+	return nil
+}
+
+// responsesContent converts Responses content parts into chat content; text-only parts are joined into a string.
+// responsesContent 는 Responses 콘텐츠 파트를 chat 콘텐츠로 변환하며, 텍스트만 있으면 문자열로 합칩니다.
+func responsesContent(content any) any {
+	switch v := content.(type) {
+	case string:
+		return v
+	case []any:
+		var parts []any
+		var texts []string
+		textOnly := true
+		for _, raw := range v {
+			part, ok := raw.(map[string]any)
+			if !ok {
+				continue
+			}
+			switch toString(part["type"]) {
+			case "input_text", "output_text", "text":
+				text := toString(part["text"])
+				texts = append(texts, text)
+				parts = append(parts, map[string]any{"type": "text", "text": text})
+			case "input_image":
+				url := toString(part["image_url"])
+				if url == "" {
+					continue
+				}
+				image := map[string]any{"url": url}
+				if detail := toString(part["detail"]); detail != "" {
+					image["detail"] = detail
+				}
+				parts = append(parts, map[string]any{"type": "image_url", "image_url": image})
+				textOnly = false
+			}
+		}
+		if len(parts) == 0 {
+			return nil
+		}
+		if textOnly {
+			return strings.Join(texts, "\n")
+		}
+		return parts
+	}
+	return nil
+}
+
+// ConvertRequestResponsesToOpenAI builds a chat/completions request from a Responses API request and the conversation messages.
+// ConvertRequestResponsesToOpenAI 는 Responses API 요청과 대화 메시지로 chat/completions 요청을 만듭니다.
+func ConvertRequestResponsesToOpenAI(body map[string]any, messages []any) map[string]any {
+	var all []any
+	if instructions := toString(body["instructions"]); instructions != "" {
+		all = append(all, map[string]any{"role": "system", "content": instructions})
+	}
+	all = append(all, messages...)
+
+	result := map[string]any{
+		"model":    body["model"],
+		"messages": all,
+	}
+	if stream, _ := body["stream"].(bool); stream {
+		result["stream"] = true
+		result["stream_options"] = map[string]any{"include_usage": true}
+	}
+	if maxTokens, ok := body["max_output_tokens"]; ok && maxTokens != nil {
+		result["max_tokens"] = maxTokens
+	}
+	for _, key := range []string{"temperature", "top_p", "parallel_tool_calls", "user"} {
+		if value, ok := body[key]; ok && value != nil {
+			result[key] = value
+		}
+	}
+	if effort := toString(nestedMapValue(body, "reasoning", "effort")); effort != "" {
+		result["reasoning_effort"] = effort
+	}
+
+	var tools []map[string]any
+	for _, raw := range toSlice(body["tools"]) {
+		tool, ok := raw.(map[string]any)
+		if !ok || toString(tool["type"]) != "function" || toString(tool["name"]) == "" {
+			continue
+		}
+		function := map[string]any{
+			"name":        toString(tool["name"]),
+			"description": toString(tool["description"]),
+			"parameters":  tool["parameters"],
+		}
+		if strict, ok := tool["strict"].(bool); ok {
+			function["strict"] = strict
+		}
+		tools = append(tools, map[string]any{"type": "function", "function": function})
+	}
+	if len(tools) > 0 {
+		result["tools"] = tools
+	}
+	switch choice := body["tool_choice"].(type) {
+	case string:
+		result["tool_choice"] = choice
+	case map[string]any:
+		if toString(choice["type"]) == "function" {
+			result["tool_choice"] = map[string]any{
+				"type":     "function",
+				"function": map[string]any{"name": toString(choice["name"])},
+			}
+		}
+	}
+
+	if format, ok := nestedMapValue(body, "text", "format").(map[string]any); ok {
+		switch toString(format["type"]) {
+		case "json_schema":
+			schema := map[string]any{
+				"name":   toString(format["name"]),
+				"schema": format["schema"],
+			}
+			if strict, ok := format["strict"].(bool); ok {
+				schema["strict"] = strict
+			}
+			if description := toString(format["description"]); description != "" {
+				schema["description"] = description
+			}
+			result["response_format"] = map[string]any{"type": "json_schema", "json_schema": schema}
+		case "json_object":
+			result["response_format"] = map[string]any{"type": "json_object"}
+		}
+	}
+	return result
+}
+
+// ConvertResponseOpenAIToResponses transforms a Copilot chat/completions response into a Responses API response object.
+// ConvertResponseOpenAIToResponses 는 Copilot chat/completions 응답을 Responses API 응답 객체로 변환합니다.
+func ConvertResponseOpenAIToResponses(body, request map[string]any, id string) (map[string]any, error) {
+	choices := toSlice(body["choices"])
+	if len(choices) == 0 {
+		return nil, errors.New("no choices in response")
+	}
+	choice, ok := choices[0].(map[string]any)
+	if !ok {
+		return nil, errors.New("invalid choice format")
+	}
+
+	result := responseObject(id, request, time.Now().Unix())
+	if model := toString(body["model"]); model != "" {
+		result["model"] = model
+	}
+	output := []any{}
+	if message, ok := choice["message"].(map[string]any); ok {
+		if text := toString(message["content"]); text != "" {
+			output = append(output, messageItem(newItemID("msg"), text, "completed"))
+		}
+		for _, raw := range toSlice(message["tool_calls"]) {
+			call, ok := raw.(map[string]any)
+			if !ok {
+				continue
+			}
+			output = append(output, functionCallItem(newItemID("fc"), toString(call["id"]), toString(nestedMapValue(call, "function", "name")), toString(nestedMapValue(call, "function", "arguments")), "completed"))
+		}
+	}
+	result["output"] = output
+	setResponseStatus(result, toString(choice["finish_reason"]))
+	if usage, ok := body["usage"].(map[string]any); ok {
+		result["usage"] = responsesUsage(usage)
+	}
+	return result, nil
+}
+
+// responseObject returns an in-progress Responses API response that echoes the request settings.
+// responseObject 는 요청 설정을 그대로 담은 진행 중 상태의 Responses API 응답을 반환합니다.
+func responseObject(id string, request map[string]any, createdAt int64) map[string]any {
+	result := map[string]any{
+		"id":                   id,
+		"object":               "response",
+		"created_at":           createdAt,
+		"status":               "in_progress",
+		"model":                toString(request["model"]),
+		"output":               []any{},
+		"error":                nil,
+		"incomplete_details":   nil,
+		"instructions":         request["instructions"],
+		"previous_response_id": request["previous_response_id"],
+		"max_output_tokens":    request["max_output_tokens"],
+		"temperature":          request["temperature"],
+		"top_p":                request["top_p"],
+		"tool_choice":          "auto",
+		"tools":                []any{},
+		"parallel_tool_calls":  true,
+		"metadata":             map[string]any{},
+		"usage":                nil,
+	}
+	for _, key := range []string{"tool_choice", "tools", "parallel_tool_calls", "metadata"} {
+		if value, ok := request[key]; ok && value != nil {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// setResponseStatus marks a response completed, or incomplete when the chat finish reason says it was cut short.
+// setResponseStatus 는 응답을 완료로 표시하며, chat 종료 사유가 중단을 뜻하면 미완료로 표시합니다.
+func setResponseStatus(result map[string]any, finishReason string) {
+	switch finishReason {
+	case "length":
+		result["status"] = "incomplete"
+		result["incomplete_details"] = map[string]any{"reason": "max_output_tokens"}
+	case "content_filter":
+		result["status"] = "incomplete"
+		result["incomplete_details"] = map[string]any{"reason": "content_filter"}
+	default:
+		result["status"] = "completed"
+	}
+}
+
+// messageItem returns an assistant message output item holding text.
+// messageItem 는 텍스트를 담은 assistant 메시지 출력 항목을 반환합니다.
+func messageItem(id, text, status string) map[string]any {
+	return map[string]any{
+		"type":   "message",
+		"id":     id,
+		"status": status,
+		"role":   "assistant",
+		"content": []any{map[string]any{
+			"type":        "output_text",
+			"text":        text,
+			"annotations": []any{},
+		}},
+	}
+}
+
+// functionCallItem returns a function call output item.
+// functionCallItem 는 함수 호출 출력 항목을 반환합니다.
+func functionCallItem(id, callID, name, arguments, status string) map[string]any {
+	return map[string]any{
+		"type":      "function_call",
+		"id":        id,
+		"call_id":   callID,
+		"name":      name,
+		"arguments": arguments,
+		"status":    status,
+	}
+}
+
+// responsesUsage converts chat/completions usage into Responses API usage.
+// responsesUsage 는 chat/completions 사용량을 Responses API 사용량으로 변환합니다.
+func responsesUsage(usage map[string]any) map[string]any {
+	input := toInt(usage["prompt_tokens"])
+	output := toInt(usage["completion_tokens"])
+	total := toInt(usage["total_tokens"])
+	if total == 0 {
+		total = input + output
+	}
+	return map[string]any{
+		"input_tokens": input,
+		"input_tokens_details": map[string]any{
+			"cached_tokens": toInt(nestedMapValue(usage, "prompt_tokens_details", "cached_tokens")),
+		},
+		"output_tokens": output,
+		"output_tokens_details": map[string]any{
+			"reasoning_tokens": toInt(nestedMapValue(usage, "completion_tokens_details", "reasoning_tokens")),
+		},
+		"total_tokens": total,
+	}
+}
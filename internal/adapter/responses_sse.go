@@ -0,0 +1,358 @@
+package adapter
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ilcm96/gh-copilot-proxy/internal/httpx"
+)
+
+// responsesStreamItem tracks one output item while a Responses stream is open.
+// responsesStreamItem 는 Responses 스트림이 열려 있는 동안 출력 항목 하나를 추적합니다.
+type responsesStreamItem struct {
+	id          string
+	outputIndex int
+	function    bool
+	callID      string
+	name        string
+	// text holds the message text, or the arguments of a function call.
+	// text 는 메시지 텍스트나 함수 호출 인자를 담습니다.
+	text strings.Builder
+}
+
+// responsesStream converts an OpenAI chat/completions SSE stream into Responses API events.
+// responsesStream 는 OpenAI chat/completions SSE 스트림을 Responses API 이벤트로 변환합니다.
+type responsesStream struct {
+	w        io.Writer
+	flusher  http.Flusher
+	response map[string]any
+	sequence int
+
+	items        []*responsesStreamItem
+	message      *responsesStreamItem
+	calls        map[int]*responsesStreamItem
+	finishReason string
+	usage        map[string]any
+	failed       bool
+}
+
+// TransformOpenAIResponseToResponses writes a Copilot chat/completions response as a Responses API response and returns the final response object.
+// TransformOpenAIResponseToResponses 는 Copilot chat/completions 응답을 Responses API 응답으로 작성하고 최종 응답 객체를 반환합니다.
+func TransformOpenAIResponseToResponses(w http.ResponseWriter, resp *http.Response, request map[string]any, id string) (map[string]any, error) {
+	httpx.CopyHeaders(w.Header(), resp.Header)
+	w.Header().Del("Content-Length")
+
+	contentType := strings.ToLower(resp.Header.Get("Content-Type"))
+	if strings.Contains(contentType, "text/event-stream") {
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(resp.StatusCode)
+		flusher, _ := w.(http.Flusher)
+		stream := &responsesStream{
+			w:        w,
+			flusher:  flusher,
+			response: responseObject(id, request, time.Now().Unix()),
+			calls:    make(map[int]*responsesStreamItem),
+		}
+		return stream.pipe(resp.Body)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+	converted, err := ConvertResponseOpenAIToResponses(payload, request, id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(converted)
+	if err != nil {
+		return nil, err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(resp.StatusCode)
+	_, err = w.Write(data)
+	return converted, err
+}
+
+// pipe reads the upstream stream, emits Responses events and returns the final response; a failed stream returns nil.
+// pipe 는 업스트림 스트림을 읽어 Responses 이벤트를 보내고 최종 응답을 반환하며, 실패한 스트림은 nil 을 반환합니다.
+func (s *responsesStream) pipe(reader io.Reader) (map[string]any, error) {
+	if err := s.emit("response.created", map[string]any{"response": s.response}); err != nil {
+		return nil, err
+	}
+	if err := s.emit("response.in_progress", map[string]any{"response": s.response}); err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	scanner.Split(splitDoubleNewline)
+	for scanner.Scan() {
+		for _, line := range strings.Split(scanner.Text(), "\n") {
+			data, ok := strings.CutPrefix(strings.TrimSpace(line), "data:")
+			if !ok {
+				continue
+			}
+			data = strings.TrimSpace(data)
+			if data == "[DONE]" {
+				return s.finish()
+			}
+			var chunk map[string]any
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				continue
+			}
+			if err := s.processChunk(chunk); err != nil {
+				return nil, err
+			}
+			if s.failed {
+				return nil, nil
+			}
+		}
+	}
+	err := scanner.Err()
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+	if err == nil && s.finishReason != "" {
+		return s.finish()
+	}
+	// Without [DONE] or a finish reason the turn was cut short, so it fails instead of completing and is not stored.
+	// [DONE] 이나 종료 사유 없이 끝난 턴은 잘린 것이므로 완료 대신 실패로 알리고 저장하지 않습니다.
+	if failErr := s.fail("upstream stream ended before the response was complete"); err == nil {
+		err = failErr
+	}
+	return nil, err
+}
+
+// fail marks the response failed and emits the error and response.failed events.
+// fail 은 응답을 실패로 표시하고 error 와 response.failed 이벤트를 보냅니다.
+func (s *responsesStream) fail(message string) error {
+	s.failed = true
+	s.response["status"] = "failed"
+	s.response["error"] = map[string]any{"code": "server_error", "message": message}
+	if err := s.emit("error", map[string]any{"code": "server_error", "message": message, "param": nil}); err != nil {
+		return err
+	}
+	return s.emit("response.failed", map[string]any{"response": s.response})
+}
+
+// processChunk converts one chat/completions chunk into Responses events.
+// processChunk 는 chat/completions 청크 하나를 Responses 이벤트로 변환합니다.
+func (s *responsesStream) processChunk(chunk map[string]any) error {
+	if errPayload, ok := chunk["error"].(map[string]any); ok {
+		message := toString(errPayload["message"])
+		if message == "" {
+			message = stringifyJSON(errPayload)
+		}
+		return s.fail(message)
+	}
+	if model := toString(chunk["model"]); model != "" {
+		s.response["model"] = model
+	}
+	if usage, ok := chunk["usage"].(map[string]any); ok {
+		s.usage = usage
+	}
+
+	choices := toSlice(chunk["choices"])
+	if len(choices) == 0 {
+		return nil
+	}
+	choice, ok := choices[0].(map[string]any)
+	if !ok {
+		return nil
+	}
+	if reason := toString(choice["finish_reason"]); reason != "" {
+		s.finishReason = reason
+	}
+	delta, _ := choice["delta"].(map[string]any)
+
+	text := toString(delta["content"])
+	if parts := toSlice(delta["content"]); parts != nil {
+		text = toString(nestedMapValue(parts, 0, "text"))
+	}
+	if text != "" {
+		if err := s.appendText(text); err != nil {
+			return err
+		}
+	}
+
+	for position, raw := range toSlice(delta["tool_calls"]) {
+		call, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		index := position
+		if _, ok := call["index"]; ok {
+			index = toInt(call["index"])
+		}
+		if err := s.appendCall(index, call); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendText opens the message item on first use and emits a text delta.
+// appendText 는 처음 사용할 때 메시지 항목을 열고 텍스트 델타를 보냅니다.
+func (s *responsesStream) appendText(text string) error {
+	if s.message == nil {
+		s.message = &responsesStreamItem{id: newItemID("msg"), outputIndex: len(s.items)}
+		s.items = append(s.items, s.message)
+		if err := s.emit("response.output_item.added", map[string]any{
+			"output_index": s.message.outputIndex,
+			"item":         messageItemInProgress(s.message.id),
+		}); err != nil {
+			return err
+		}
+		if err := s.emit("response.content_part.added", map[string]any{
+			"item_id":       s.message.id,
+			"output_index":  s.message.outputIndex,
+			"content_index": 0,
+			"part":          map[string]any{"type": "output_text", "text": "", "annotations": []any{}},
+		}); err != nil {
+			return err
+		}
+	}
+	s.message.text.WriteString(text)
+	return s.emit("response.output_text.delta", map[string]any{
+		"item_id":       s.message.id,
+		"output_index":  s.message.outputIndex,
+		"content_index": 0,
+		"delta":         text,
+	})
+}
+
+// appendCall opens a function call item on first sight of its index and emits argument deltas.
+// appendCall 는 인덱스를 처음 볼 때 함수 호출 항목을 열고 인자 델타를 보냅니다.
+func (s *responsesStream) appendCall(index int, call map[string]any) error {
+	item, ok := s.calls[index]
+	if !ok {
+		item = &responsesStreamItem{
+			id:          newItemID("fc"),
+			outputIndex: len(s.items),
+			function:    true,
+			callID:      toString(call["id"]),
+			name:        toString(nestedMapValue(call, "function", "name")),
+		}
+		s.calls[index] = item
+		s.items = append(s.items, item)
+		if err := s.emit("response.output_item.added", map[string]any{
+			"output_index": item.outputIndex,
+			"item":         functionCallItem(item.id, item.callID, item.name, "", "in_progress"),
+		}); err != nil {
+			return err
+		}
+	}
+	arguments := toString(nestedMapValue(call, "function", "arguments"))
+	if arguments == "" {
+		return nil
+	}
+	item.text.WriteString(arguments)
+	return s.emit("response.function_call_arguments.delta", map[string]any{
+		"item_id":      item.id,
+		"output_index": item.outputIndex,
+		"delta":        arguments,
+	})
+}
+
+// finish closes every open item and emits the terminal response event.
+// finish 는 열린 항목을 모두 닫고 마지막 응답 이벤트를 보냅니다.
+func (s *responsesStream) finish() (map[string]any, error) {
+	output := make([]any, 0, len(s.items))
+	for _, item := range s.items {
+		text := item.text.String()
+		var done map[string]any
+		if item.function {
+			if err := s.emit("response.function_call_arguments.done", map[string]any{
+				"item_id":      item.id,
+				"output_index": item.outputIndex,
+				"arguments":    text,
+			}); err != nil {
+				return nil, err
+			}
+			done = functionCallItem(item.id, item.callID, item.name, text, "completed")
+		} else {
+			part := map[string]any{"type": "output_text", "text": text, "annotations": []any{}}
+			if err := s.emit("response.output_text.done", map[string]any{
+				"item_id":       item.id,
+				"output_index":  item.outputIndex,
+				"content_index": 0,
+				"text":          text,
+			}); err != nil {
+				return nil, err
+			}
+			if err := s.emit("response.content_part.done", map[string]any{
+				"item_id":       item.id,
+				"output_index":  item.outputIndex,
+				"content_index": 0,
+				"part":          part,
+			}); err != nil {
+				return nil, err
+			}
+			done = messageItem(item.id, text, "completed")
+		}
+		if err := s.emit("response.output_item.done", map[string]any{
+			"output_index": item.outputIndex,
+			"item":         done,
+		}); err != nil {
+			return nil, err
+		}
+		output = append(output, done)
+	}
+
+	s.response["output"] = output
+	setResponseStatus(s.response, s.finishReason)
+	if s.usage != nil {
+		s.response["usage"] = responsesUsage(s.usage)
+	}
+	event := "response.completed"
+	if s.response["status"] == "incomplete" {
+		event = "response.incomplete"
+	}
+	if err := s.emit(event, map[string]any{"response": s.response}); err != nil {
+		return nil, err
+	}
+	return s.response, nil
+}
+
+// emit writes one Responses event with its type and sequence number.
+// emit 는 유형과 순번을 붙인 Responses 이벤트 하나를 작성합니다.
+func (s *responsesStream) emit(event string, payload map[string]any) error {
+	payload["type"] = event
+	payload["sequence_number"] = s.sequence
+	s.sequence++
+	data, err := marshalEventPayload(payload)
+	if err != nil {
+		return err
+	}
+	if _, err := s.w.Write(buildEvent(event, data)); err != nil {
+		return err
+	}
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+	return nil
+}
+
+// messageItemInProgress returns an empty assistant message item that is still being streamed.
+// messageItemInProgress 는 아직 스트리밍 중인 빈 assistant 메시지 항목을 반환합니다.
+func messageItemInProgress(id string) map[string]any {
+	return map[string]any{
+		"type":    "message",
+		"id":      id,
+		"status":  "in_progress",
+		"role":    "assistant",
+		"content": []any{},
+	}
+}
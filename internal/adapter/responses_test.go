@@ -0,0 +1,287 @@
+package adapter
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// upstreamResponse returns a chat/completions response with the given content type and body.
+func upstreamResponse(contentType, body string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {contentType}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestResponsesInputToMessages(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "string input",
+			input: `"Hello"`,
+			want:  `[{"role":"user","content":"Hello"}]`,
+		},
+		{
+			name:  "empty string input",
+			input: `""`,
+			want:  `null`,
+		},
+		{
+			name:  "developer becomes system and text parts are joined",
+			input: `[{"role":"developer","content":"Be brief."},{"type":"message","role":"user","content":[{"type":"input_text","text":"a"},{"type":"input_text","text":"b"}]}]`,
+			want:  `[{"role":"system","content":"Be brief."},{"role":"user","content":"a\nb"}]`,
+		},
+		{
+			name:  "images keep the parts",
+			input: `[{"role":"user","content":[{"type":"input_text","text":"What is this?"},{"type":"input_image","image_url":"data:image/png;base64,AA","detail":"low"}]}]`,
+			want:  `[{"role":"user","content":[{"type":"text","text":"What is this?"},{"type":"image_url","image_url":{"url":"data:image/png;base64,AA","detail":"low"}}]}]`,
+		},
+		{
+			name: "function calls join the preceding assistant message",
+			input: `[
+				{"type":"message","role":"assistant","content":[{"type":"output_text","text":"Checking."}]},
+				{"type":"function_call","call_id":"call_1","name":"get_weather","arguments":"{\"city\":\"Seoul\"}"},
+				{"type":"function_call","call_id":"call_2","name":"get_time","arguments":"{}"},
+				{"type":"function_call_output","call_id":"call_1","output":"sunny"}
+			]`,
+			want: `[
+				{"role":"assistant","content":"Checking.","tool_calls":[
+					{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Seoul\"}"}},
+					{"id":"call_2","type":"function","function":{"name":"get_time","arguments":"{}"}}
+				]},
+				{"role":"tool","tool_call_id":"call_1","content":"sunny"}
+			]`,
+		},
+		{
+			name:  "function call without a preceding assistant message",
+			input: `[{"type":"function_call","call_id":"call_1","name":"f","arguments":"{}"}]`,
+			want:  `[{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"f","arguments":"{}"}}]}]`,
+		},
+		{
+			name:  "unknown items and roles are skipped",
+			input: `[{"type":"reasoning","summary":[]},{"role":"tool","content":"x"}]`,
+			want:  `null`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input any
+			if err := json.Unmarshal([]byte(tt.input), &input); err != nil {
+				t.Fatal(err)
+			}
+			assertJSON(t, ResponsesInputToMessages(input), tt.want)
+		})
+	}
+}
+
+func TestConvertRequestResponsesToOpenAI(t *testing.T) {
+	var body map[string]any
+	err := json.Unmarshal([]byte(`{
+		"model": "gpt-4o",
+		"instructions": "Be brief.",
+		"max_output_tokens": 50,
+		"stream": true,
+		"reasoning": {"effort": "low"},
+		"tools": [
+			{"type":"function","name":"get_weather","description":"Weather","parameters":{"type":"object"},"strict":true},
+			{"type":"web_search"}
+		],
+		"tool_choice": {"type":"function","name":"get_weather"},
+		"text": {"format": {"type":"json_schema","name":"answer","schema":{"type":"object"},"strict":true}}
+	}`), &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := []any{map[string]any{"role": "user", "content": "Hi"}}
+	assertJSON(t, ConvertRequestResponsesToOpenAI(body, messages), `{
+		"model": "gpt-4o",
+		"messages": [{"role":"system","content":"Be brief."},{"role":"user","content":"Hi"}],
+		"stream": true,
+		"stream_options": {"include_usage":true},
+		"max_tokens": 50,
+		"reasoning_effort": "low",
+		"tools": [{"type":"function","function":{"name":"get_weather","description":"Weather","parameters":{"type":"object"},"strict":true}}],
+		"tool_choice": {"type":"function","function":{"name":"get_weather"}},
+		"response_format": {"type":"json_schema","json_schema":{"name":"answer","schema":{"type":"object"},"strict":true}}
+	}`)
+}
+
+func TestConvertResponseOpenAIToResponses(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus string
+		wantItems  []string
+	}{
+		{
+			name:       "text and tool call",
+			body:       `{"model":"gpt-4o","choices":[{"message":{"content":"Checking.","tool_calls":[{"id":"call_1","function":{"name":"get_weather","arguments":"{}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":5,"completion_tokens":3}}`,
+			wantStatus: "completed",
+			wantItems:  []string{"message", "function_call"},
+		},
+		{
+			name:       "length is incomplete",
+			body:       `{"model":"gpt-4o","choices":[{"message":{"content":"cut"},"finish_reason":"length"}]}`,
+			wantStatus: "incomplete",
+			wantItems:  []string{"message"},
+		},
+	}
+	request := map[string]any{"model": "gpt-4o", "instructions": "Be brief."}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]any
+			if err := json.Unmarshal([]byte(tt.body), &body); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ConvertResponseOpenAIToResponses(body, request, "resp_1")
+			if err != nil {
+				t.Fatalf("ConvertResponseOpenAIToResponses() error = %v", err)
+			}
+			if got["id"] != "resp_1" || got["object"] != "response" || got["instructions"] != "Be brief." {
+				t.Errorf("id = %v, object = %v, instructions = %v", got["id"], got["object"], got["instructions"])
+			}
+			if got["status"] != tt.wantStatus {
+				t.Errorf("status = %v, want %s", got["status"], tt.wantStatus)
+			}
+			var items []string
+			for _, item := range toSlice(got["output"]) {
+				items = append(items, toString(nestedMapValue(item, "type")))
+			}
+			if !slices.Equal(items, tt.wantItems) {
+				t.Errorf("output types = %q, want %q", items, tt.wantItems)
+			}
+		})
+	}
+
+	got, _ := ConvertResponseOpenAIToResponses(map[string]any{
+		"choices": []any{map[string]any{"message": map[string]any{"content": "x"}}},
+		"usage":   map[string]any{"prompt_tokens": 5, "completion_tokens": 3},
+	}, request, "resp_1")
+	assertJSON(t, got["usage"], `{"input_tokens":5,"input_tokens_details":{"cached_tokens":0},"output_tokens":3,"output_tokens_details":{"reasoning_tokens":0},"total_tokens":8}`)
+
+	if _, err := ConvertResponseOpenAIToResponses(map[string]any{"choices": []any{}}, request, "resp_1"); err == nil {
+		t.Error("ConvertResponseOpenAIToResponses() without choices returned no error")
+	}
+}
+
+func TestTransformOpenAIResponseToResponsesStream(t *testing.T) {
+	upstream := "data: {\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\",\"content\":\"Hel\"}}]}\n\n" +
+		"data: {\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"lo\"}}]}\n\n" +
+		"data: {\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"index\":0,\"id\":\"call_1\",\"function\":{\"name\":\"f\",\"arguments\":\"{\\\"a\\\":\"}}]}}]}\n\n" +
+		"data: {\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":\"1}\"}}]},\"finish_reason\":\"tool_calls\"}],\"usage\":{\"prompt_tokens\":4,\"completion_tokens\":6,\"total_tokens\":10}}\n\n" +
+		"data: [DONE]\n\n"
+	rec := httptest.NewRecorder()
+	final, err := TransformOpenAIResponseToResponses(rec, upstreamResponse("text/event-stream", upstream), map[string]any{"model": "gpt-4o", "stream": true}, "resp_1")
+	if err != nil {
+		t.Fatalf("TransformOpenAIResponseToResponses() error = %v", err)
+	}
+
+	var events []string
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if event, ok := strings.CutPrefix(line, "event: "); ok {
+			events = append(events, event)
+		}
+	}
+	if events[0] != "response.created" || events[len(events)-1] != "response.completed" {
+		t.Errorf("events = %q, want response.created … response.completed", events)
+	}
+	for _, want := range []string{"response.output_text.delta", "response.output_text.done", "response.function_call_arguments.delta", "response.function_call_arguments.done"} {
+		if !slices.Contains(events, want) {
+			t.Errorf("events = %q, missing %s", events, want)
+		}
+	}
+
+	if final["status"] != "completed" {
+		t.Errorf("final status = %v, want completed", final["status"])
+	}
+	output := toSlice(final["output"])
+	if len(output) != 2 {
+		t.Fatalf("final output = %v, want a message and a function call", output)
+	}
+	if text := nestedMapValue(output[0], "content", 0, "text"); text != "Hello" {
+		t.Errorf("message text = %v, want Hello", text)
+	}
+	if args := nestedMapValue(output[1], "arguments"); args != `{"a":1}` {
+		t.Errorf("function call arguments = %v, want {\"a\":1}", args)
+	}
+	if total := nestedMapValue(final, "usage", "total_tokens"); toInt(total) != 10 {
+		t.Errorf("usage total_tokens = %v, want 10", total)
+	}
+}
+
+func TestTransformOpenAIResponseToResponsesStreamEnd(t *testing.T) {
+	chunk := "data: {\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hel\"}}]}\n\n"
+	tests := []struct {
+		name      string
+		upstream  string
+		wantEvent string
+		wantFinal bool
+	}{
+		{
+			name:      "finish reason without [DONE] completes",
+			upstream:  chunk + "data: {\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n",
+			wantEvent: "response.completed",
+			wantFinal: true,
+		},
+		{
+			name:      "cut off before a finish reason fails",
+			upstream:  chunk,
+			wantEvent: "response.failed",
+		},
+		{
+			name:      "empty stream fails",
+			wantEvent: "response.failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			final, err := TransformOpenAIResponseToResponses(rec, upstreamResponse("text/event-stream", tt.upstream), map[string]any{"model": "gpt-4o", "stream": true}, "resp_1")
+			if err != nil {
+				t.Fatalf("TransformOpenAIResponseToResponses() error = %v", err)
+			}
+			var events []string
+			for _, line := range strings.Split(rec.Body.String(), "\n") {
+				if event, ok := strings.CutPrefix(line, "event: "); ok {
+					events = append(events, event)
+				}
+			}
+			if last := events[len(events)-1]; last != tt.wantEvent {
+				t.Errorf("last event = %s, want %s", last, tt.wantEvent)
+			}
+			// A truncated turn returns no final response, so it is not stored.
+			if (final != nil) != tt.wantFinal {
+				t.Errorf("final = %v, want a final response %t", final, tt.wantFinal)
+			}
+		})
+	}
+}
+
+// assertJSON compares got with the JSON document want after a JSON round trip.
+func assertJSON(t *testing.T, got any, want string) {
+	t.Helper()
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gotJSON, wantJSON any
+	if err := json.Unmarshal(data, &gotJSON); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(want), &wantJSON); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gotJSON, wantJSON) {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
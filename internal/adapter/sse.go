@@ -12,8 +12,6 @@ import (
 	"regexp"
 	"strings"
 	"time"
-
-	"github.com/ilcm96/gh-copilot-proxy/internal/httpx"
 )
 
 // toolCallState preserves tool call tracking state during streaming.
@@ -56,8 +54,7 @@ func newSSEConverter() *sseConverter {
 // TransformOpenAIResponseToAnthropic converts a Copilot response into an Anthropic-compatible format.
 // TransformOpenAIResponseToAnthropic 는 Copilot 응답을 Anthropic 호환 형식으로 변환합니다.
 func TransformOpenAIResponseToAnthropic(w http.ResponseWriter, resp *http.Response) error {
-	httpx.CopyHeaders(w.Header(), resp.Header)
-	w.Header().Del("Content-Length")
+	CopyAnthropicHeaders(w.Header(), resp.Header, resp.StatusCode, time.Now())
 
 	contentType := strings.ToLower(resp.Header.Get("Content-Type"))
 	if strings.Contains(contentType, "text/event-stream") {
@@ -173,11 +170,15 @@ func (c *sseConverter) processLine(line string) ([][]byte, error) {
 	}
 
 	if errPayload, ok := body["error"].(map[string]any); ok {
+		message, _ := errPayload["message"].(string)
+		if message == "" {
+			message = stringifyJSON(errPayload)
+		}
 		payload := map[string]any{
 			"type": "error",
-			"message": map[string]any{
+			"error": map[string]any{
 				"type":    "api_error",
-				"message": stringifyJSON(errPayload),
+				"message": message,
 			},
 		}
 		data, err := marshalEventPayload(payload)
@@ -0,0 +1,135 @@
+package adapter
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+)
+
+// maxUpstreamStops is the number of stop sequences chat/completions accepts.
+// maxUpstreamStops 는 chat/completions 가 받는 중단 시퀀스의 개수입니다.
+const maxUpstreamStops = 4
+
+// stopScanner cuts generated text at the first stop sequence, holding back text that may be the start of one.
+// stopScanner 는 생성된 텍스트를 첫 중단 시퀀스에서 자르며, 중단 시퀀스의 시작일 수 있는 텍스트는 보류합니다.
+//
+// Stops are also sent upstream, but are enforced here as well so the matched sequence can be reported.
+// 중단 시퀀스는 업스트림에도 보내지만, 일치한 시퀀스를 알릴 수 있도록 여기서도 적용합니다.
+type stopScanner struct {
+	stops   []string
+	pending string
+	matched string
+}
+
+// feed adds generated text and returns the part that is safe to emit.
+// feed 는 생성된 텍스트를 더하고 내보내도 되는 부분을 반환합니다.
+func (s *stopScanner) feed(text string) string {
+	if s.matched != "" {
+		return ""
+	}
+	buf := s.pending + text
+	cut := -1
+	for _, stop := range s.stops {
+		if i := strings.Index(buf, stop); i >= 0 && (cut < 0 || i < cut) {
+			cut, s.matched = i, stop
+		}
+	}
+	if cut >= 0 {
+		s.pending = ""
+		return buf[:cut]
+	}
+	hold := 0
+	for _, stop := range s.stops {
+		for k := min(len(stop)-1, len(buf)); k > hold; k-- {
+			if strings.HasSuffix(buf, stop[:k]) {
+				hold = k
+				break
+			}
+		}
+	}
+	s.pending = buf[len(buf)-hold:]
+	return buf[:len(buf)-hold]
+}
+
+// flush returns the held-back text once generation has ended.
+// flush 는 생성이 끝난 뒤 보류한 텍스트를 반환합니다.
+func (s *stopScanner) flush() string {
+	pending := s.pending
+	s.pending = ""
+	return pending
+}
+
+// stopSequences reads a stop parameter given as a string or a list of strings.
+// stopSequences 는 문자열이나 문자열 목록으로 주어진 중단 파라미터를 읽습니다.
+func stopSequences(v any) []string {
+	if stop, ok := v.(string); ok {
+		if stop == "" {
+			return nil
+		}
+		return []string{stop}
+	}
+	var stops []string
+	for _, raw := range toSlice(v) {
+		if stop := toString(raw); stop != "" {
+			stops = append(stops, stop)
+		}
+	}
+	return stops
+}
+
+// upstreamStops returns the stop sequences to send upstream, limited to what chat/completions accepts.
+// upstreamStops 는 업스트림에 보낼 중단 시퀀스를 chat/completions 가 받는 개수로 제한해 반환합니다.
+func upstreamStops(stops []string) []string {
+	return stops[:min(len(stops), maxUpstreamStops)]
+}
+
+// chatText returns the text and finish reason of the first choice of a chat/completions response or chunk.
+// chatText 는 chat/completions 응답이나 청크의 첫 번째 선택지에서 텍스트와 종료 사유를 반환합니다.
+func chatText(body map[string]any) (string, string) {
+	choice, _ := nestedMapValue(body, "choices", 0).(map[string]any)
+	if choice == nil {
+		return "", ""
+	}
+	finishReason := toString(choice["finish_reason"])
+	if message, ok := choice["message"].(map[string]any); ok {
+		return toString(message["content"]), finishReason
+	}
+	delta, _ := choice["delta"].(map[string]any)
+	if parts := toSlice(delta["content"]); parts != nil {
+		return toString(nestedMapValue(parts, 0, "text")), finishReason
+	}
+	return toString(delta["content"]), finishReason
+}
+
+// eachChatChunk calls fn for every JSON chunk of a chat/completions SSE stream until [DONE].
+// eachChatChunk 는 chat/completions SSE 스트림의 JSON 청크마다 [DONE] 까지 fn 을 호출합니다.
+func eachChatChunk(reader io.Reader, fn func(map[string]any) error) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	scanner.Split(splitDoubleNewline)
+	for scanner.Scan() {
+		for _, line := range strings.Split(scanner.Text(), "\n") {
+			data, ok := strings.CutPrefix(strings.TrimSpace(line), "data:")
+			if !ok {
+				continue
+			}
+			data = strings.TrimSpace(data)
+			if data == "[DONE]" {
+				return nil
+			}
+			var chunk map[string]any
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				continue
+			}
+			if err := fn(chunk); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
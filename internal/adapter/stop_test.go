@@ -0,0 +1,135 @@
+package adapter
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestStopScanner(t *testing.T) {
+	tests := []struct {
+		name        string
+		stops       []string
+		chunks      []string
+		want        []string
+		wantFlush   string
+		wantMatched string
+	}{
+		{
+			name:   "no stops passes text through",
+			chunks: []string{"Hello", " world"},
+			want:   []string{"Hello", " world"},
+		},
+		{
+			name:        "stop inside one chunk",
+			stops:       []string{"END"},
+			chunks:      []string{"Hello END world"},
+			want:        []string{"Hello "},
+			wantMatched: "END",
+		},
+		{
+			name:        "stop split across chunks is held back",
+			stops:       []string{"\n\nHuman:"},
+			chunks:      []string{"Hi there\n", "\nHum", "an: next"},
+			want:        []string{"Hi there", "", ""},
+			wantMatched: "\n\nHuman:",
+		},
+		{
+			name:        "held prefix completed by a later chunk",
+			stops:       []string{"END"},
+			chunks:      []string{"the EN", "D", "ing"},
+			want:        []string{"the ", "", ""},
+			wantMatched: "END",
+		},
+		{
+			name:   "held prefix that does not complete is emitted",
+			stops:  []string{"END"},
+			chunks: []string{"the EN", "VY"},
+			want:   []string{"the ", "ENVY"},
+		},
+		{
+			name:      "trailing prefix is returned by flush",
+			stops:     []string{"END"},
+			chunks:    []string{"almost E"},
+			want:      []string{"almost "},
+			wantFlush: "E",
+		},
+		{
+			name:        "earliest of several stops wins",
+			stops:       []string{"world", "lo"},
+			chunks:      []string{"Hello world"},
+			want:        []string{"Hel"},
+			wantMatched: "lo",
+		},
+		{
+			name:        "text after a match is dropped",
+			stops:       []string{"."},
+			chunks:      []string{"One.", " Two."},
+			want:        []string{"One", ""},
+			wantMatched: ".",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &stopScanner{stops: tt.stops}
+			var got []string
+			for _, chunk := range tt.chunks {
+				got = append(got, s.feed(chunk))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("feed() = %q, want %q", got, tt.want)
+			}
+			if flushed := s.flush(); flushed != tt.wantFlush {
+				t.Errorf("flush() = %q, want %q", flushed, tt.wantFlush)
+			}
+			if s.matched != tt.wantMatched {
+				t.Errorf("matched = %q, want %q", s.matched, tt.wantMatched)
+			}
+		})
+	}
+}
+
+func TestStopSequences(t *testing.T) {
+	tests := []struct {
+		name string
+		in   any
+		want []string
+	}{
+		{name: "string", in: "END", want: []string{"END"}},
+		{name: "empty string", in: "", want: nil},
+		{name: "list skips empty entries", in: []any{"a", "", "b"}, want: []string{"a", "b"}},
+		{name: "missing", in: nil, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stopSequences(tt.in); !slices.Equal(got, tt.want) {
+				t.Errorf("stopSequences(%v) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEachChatChunk(t *testing.T) {
+	stream := "data: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n" +
+		": keep-alive\n\n" +
+		"data: {\"choices\":[{\"delta\":{\"content\":\"lo\"},\"finish_reason\":\"stop\"}]}\n\n" +
+		"data: [DONE]\n\n" +
+		"data: {\"choices\":[{\"delta\":{\"content\":\"after\"}}]}\n\n"
+	var text strings.Builder
+	var reasons []string
+	err := eachChatChunk(strings.NewReader(stream), func(chunk map[string]any) error {
+		delta, reason := chatText(chunk)
+		text.WriteString(delta)
+		reasons = append(reasons, reason)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("eachChatChunk() error = %v", err)
+	}
+	if text.String() != "Hello" {
+		t.Errorf("text = %q, want %q", text.String(), "Hello")
+	}
+	if !slices.Equal(reasons, []string{"", "stop"}) {
+		t.Errorf("finish reasons = %q, want %q", reasons, []string{"", "stop"})
+	}
+}
@@ -2,7 +2,6 @@ package auth
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -16,17 +15,29 @@ import (
 	"time"
 )
 
-const copilotAuthURL = "https://api.github.com/copilot_internal/v2/token"
+const (
+	copilotAuthURL    = "https://api.github.com/copilot_internal/v2/token"
+	copilotAPIBaseURL = "https://api.githubcopilot.com"
+)
 
 // CopilotAuth manages GitHub Copilot credentials.
 // CopilotAuth 는 GitHub Copilot 자격 증명을 관리합니다.
 type CopilotAuth struct {
-	oauthToken string
+	name string
+
+	mu             sync.RWMutex
+	oauthToken     string
+	githubToken    *CopilotToken
+	throttledUntil time.Time
+	failures       int
+	allowDegraded  bool
 
-	mu          sync.RWMutex
-	githubToken map[string]any
+	refreshMu  sync.Mutex
+	refreshing *refreshCall
 
-	configDir string
+	sources  []TokenSource
+	tokenURL string
+	cache    *tokenCache
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -36,37 +47,86 @@ type CopilotAuth struct {
 // NewCopilotAuth creates a CopilotAuth that manages GitHub Copilot credentials.
 // NewCopilotAuth 는 GitHub Copilot 자격 증명을 관리할 CopilotAuth 를 생성합니다.
 func NewCopilotAuth(parent context.Context) (*CopilotAuth, error) {
-	home, err := os.UserHomeDir()
+	configDir, err := defaultConfigDir()
 	if err != nil {
-		return nil, fmt.Errorf("resolve user home: %w", err)
+		return nil, err
 	}
-	var configDir string
-	if runtime.GOOS == "windows" {
-		configDir = filepath.Join(home, "AppData", "Local")
-	} else {
-		configDir = filepath.Join(home, ".config")
+	sources, err := defaultSources(configDir)
+	if err != nil {
+		return nil, err
 	}
+	cache, err := newTokenCache()
+	if err != nil {
+		return nil, err
+	}
+	a := newAccount(parent, "default", "", cache)
+	a.sources = sources
+	return a, nil
+}
+
+// newAccount creates a CopilotAuth bound to a single OAuth token.
+// newAccount 는 하나의 OAuth 토큰에 묶인 CopilotAuth 를 생성합니다.
+func newAccount(parent context.Context, name, oauthToken string, cache *tokenCache) *CopilotAuth {
 	ctx, cancel := context.WithCancel(parent)
 	return &CopilotAuth{
-		configDir: configDir,
-		ctx:       ctx,
-		cancel:    cancel,
-	}, nil
+		name:          name,
+		oauthToken:    oauthToken,
+		tokenURL:      envOrDefault("COPILOT_TOKEN_URL", copilotAuthURL),
+		cache:         cache,
+		allowDegraded: envOrDefault("COPILOT_DEGRADED_START", "") == "true",
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+}
+
+// defaultConfigDir returns the directory where editor plugins keep github-copilot settings.
+// defaultConfigDir 는 에디터 플러그인이 github-copilot 설정을 보관하는 디렉터리를 반환합니다.
+func defaultConfigDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve user home: %w", err)
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(home, "AppData", "Local"), nil
+	}
+	return filepath.Join(home, ".config"), nil
+}
+
+// envOrDefault returns the trimmed environment variable value or def when it is empty.
+// envOrDefault 는 환경 변수 값을 공백 제거 후 반환하며, 비어 있으면 def 를 반환합니다.
+func envOrDefault(key, def string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return def
 }
 
 // Setup prepares the OAuth token and starts periodic refresh work.
 // Setup 는 OAuth 토큰을 준비하고 주기적인 갱신 작업을 시작합니다.
+//
+// A still-valid token from the on-disk cache is used instead of refreshing at startup.
+// 디스크 캐시에 아직 유효한 토큰이 있으면 기동 시 갱신 대신 이를 사용합니다.
 func (a *CopilotAuth) Setup() error {
-	oauth, err := a.getOAuthToken()
-	if err != nil {
-		return err
+	if a.oauth() == "" {
+		tokens, _, err := resolveOAuthTokens(a.ctx, a.sources)
+		if err != nil {
+			return err
+		}
+		a.mu.Lock()
+		a.oauthToken = tokens[0]
+		a.mu.Unlock()
 	}
-	a.oauthToken = oauth
 
-	if ok, err := a.RefreshToken(true); err != nil {
-		return err
-	} else if !ok {
-		return errors.New("failed to refresh token during startup")
+	if cached := a.cache.load(a.oauth()); cached.Valid(2 * time.Minute) {
+		a.mu.Lock()
+		a.githubToken = cached
+		a.mu.Unlock()
+		log.Printf("%s: loaded cached token valid until %s", a.name, cached.ExpiresAt.Format(time.RFC3339))
+	} else if err := a.startupRefresh(); err != nil {
+		if !a.allowDegraded {
+			return err
+		}
+		log.Printf("%s: starting in degraded mode: %v", a.name, err)
 	}
 
 	a.wg.Add(1)
@@ -74,6 +134,19 @@ func (a *CopilotAuth) Setup() error {
 	return nil
 }
 
+// startupRefresh performs the initial token refresh.
+// startupRefresh 는 최초 토큰 갱신을 수행합니다.
+func (a *CopilotAuth) startupRefresh() error {
+	ok, err := a.RefreshToken(true)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("failed to refresh token during startup")
+	}
+	return nil
+}
+
 // Cleanup tears down any running background tasks.
 // Cleanup 는 실행 중인 백그라운드 작업을 정리합니다.
 func (a *CopilotAuth) Cleanup() {
@@ -81,6 +154,55 @@ func (a *CopilotAuth) Cleanup() {
 	a.wg.Wait()
 }
 
+// Name returns the label used to identify this account in logs.
+// Name 는 로그에서 이 계정을 식별하는 이름을 반환합니다.
+func (a *CopilotAuth) Name() string {
+	return a.name
+}
+
+// MarkThrottled takes the account out of rotation for the given duration.
+// MarkThrottled 는 지정된 시간 동안 계정을 순환 대상에서 제외합니다.
+func (a *CopilotAuth) MarkThrottled(d time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.throttledUntil = time.Now().Add(d)
+}
+
+// throttledAt returns the time until which the account is throttled.
+// throttledAt 는 계정이 제한된 상태로 유지되는 시각을 반환합니다.
+func (a *CopilotAuth) throttledAt() time.Time {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.throttledUntil
+}
+
+// oauth returns the GitHub OAuth token used for token exchange.
+// oauth 는 토큰 교환에 사용하는 GitHub OAuth 토큰을 반환합니다.
+func (a *CopilotAuth) oauth() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.oauthToken
+}
+
+// SetOAuthToken swaps in a new GitHub OAuth token and forces a Copilot token refresh with it.
+// SetOAuthToken 는 새 GitHub OAuth 토큰으로 교체하고 이를 사용해 Copilot 토큰을 강제로 갱신합니다.
+func (a *CopilotAuth) SetOAuthToken(token string) error {
+	a.mu.Lock()
+	a.oauthToken = token
+	a.mu.Unlock()
+
+	// A refresh already in flight still uses the previous token, so wait for it before forcing ours.
+	// 진행 중인 갱신은 이전 토큰을 사용하므로, 끝나기를 기다린 뒤 새 갱신을 강제합니다.
+	a.refreshMu.Lock()
+	call := a.refreshing
+	a.refreshMu.Unlock()
+	if call != nil {
+		<-call.done
+	}
+	_, err := a.RefreshToken(true)
+	return err
+}
+
 // BearerToken returns the Copilot API token currently stored in memory.
 // BearerToken 는 현재 메모리에 저장된 Copilot API 토큰을 반환합니다.
 func (a *CopilotAuth) BearerToken() string {
@@ -89,26 +211,96 @@ func (a *CopilotAuth) BearerToken() string {
 	if a.githubToken == nil {
 		return ""
 	}
-	if token, ok := a.githubToken["token"].(string); ok {
-		return token
+	return a.githubToken.Token
+}
+
+// Token returns the parsed Copilot token, or nil before the first refresh.
+// Token 는 파싱된 Copilot 토큰을 반환하며, 첫 갱신 전에는 nil 을 반환합니다.
+//
+// The returned value is shared and must not be modified.
+// 반환값은 공유되므로 수정해서는 안 됩니다.
+func (a *CopilotAuth) Token() *CopilotToken {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.githubToken
+}
+
+// APIBaseURL returns the Copilot API base URL advertised by the current token.
+// APIBaseURL 는 현재 토큰이 알려 주는 Copilot API 기본 URL 을 반환합니다.
+func (a *CopilotAuth) APIBaseURL() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.githubToken != nil && a.githubToken.Endpoints.API != "" {
+		return strings.TrimRight(a.githubToken.Endpoints.API, "/")
 	}
-	return ""
+	return copilotAPIBaseURL
 }
 
 // RefreshToken fetches a new Copilot token from GitHub when necessary.
 // RefreshToken 는 필요 시 GitHub API 에 요청하여 새 Copilot 토큰을 가져옵니다.
+//
+// Concurrent callers share a single in-flight request.
+// 동시에 호출되면 진행 중인 하나의 요청 결과를 공유합니다.
 func (a *CopilotAuth) RefreshToken(force bool) (bool, error) {
 	if !force && a.isTokenValid() {
 		return true, nil
 	}
+
+	a.refreshMu.Lock()
+	if call := a.refreshing; call != nil {
+		a.refreshMu.Unlock()
+		<-call.done
+		return call.ok, call.err
+	}
+	call := &refreshCall{done: make(chan struct{})}
+	a.refreshing = call
+	a.refreshMu.Unlock()
+
+	call.ok, call.err = a.fetchToken()
+
+	a.mu.Lock()
+	if call.err != nil || !call.ok {
+		a.failures++
+	} else {
+		a.failures = 0
+	}
+	a.mu.Unlock()
+
+	a.refreshMu.Lock()
+	a.refreshing = nil
+	a.refreshMu.Unlock()
+	close(call.done)
+	return call.ok, call.err
+}
+
+// RefreshStale forces a refresh unless the token has already changed since stale was handed out.
+// RefreshStale 는 stale 토큰이 이미 교체되지 않았다면 강제로 토큰을 갱신합니다.
+func (a *CopilotAuth) RefreshStale(stale string) error {
+	if a.BearerToken() != stale {
+		return nil
+	}
+	ok, err := a.RefreshToken(true)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("token refresh failed")
+	}
+	return nil
+}
+
+// fetchToken requests a Copilot token from GitHub and stores it in memory.
+// fetchToken 는 GitHub 에 Copilot 토큰을 요청하고 메모리에 저장합니다.
+func (a *CopilotAuth) fetchToken() (bool, error) {
 	ctx, cancel := context.WithTimeout(a.ctx, 30*time.Second)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, copilotAuthURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.tokenURL, nil)
 	if err != nil {
 		return false, err
 	}
-	req.Header.Set("Authorization", fmt.Sprintf("token %s", a.oauthToken))
+	oauth := a.oauth()
+	req.Header.Set("Authorization", fmt.Sprintf("token %s", oauth))
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Editor-Plugin-Version", "copilot.lua")
 
@@ -123,15 +315,22 @@ func (a *CopilotAuth) RefreshToken(force bool) (bool, error) {
 		return false, fmt.Errorf("token refresh failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
 	}
 
-	var token map[string]any
-	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
-		return false, fmt.Errorf("decode token response: %w", err)
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("read token response: %w", err)
+	}
+	token, err := parseCopilotToken(data, time.Now())
+	if err != nil {
+		return false, err
 	}
 
 	a.mu.Lock()
 	a.githubToken = token
 	a.mu.Unlock()
+	if err := a.cache.store(oauth, token); err != nil {
+		log.Printf("%s: write token cache: %v", a.name, err)
+	}
 
-	log.Printf("token refreshed successfully")
+	log.Printf("%s: token refreshed successfully", a.name)
 	return true, nil
 }
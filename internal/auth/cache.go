@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ilcm96/gh-copilot-proxy/internal/fileutil"
+)
+
+// tokenCache persists Copilot tokens on disk, encrypted with AES-GCM, so restarts can skip the token exchange.
+// tokenCache 는 재시작 시 토큰 교환을 건너뛸 수 있도록 Copilot 토큰을 AES-GCM 으로 암호화해 디스크에 저장합니다.
+type tokenCache struct {
+	path string
+	aead cipher.AEAD
+	mu   sync.Mutex
+}
+
+// cacheEntry is a cached token response together with its issue time.
+// cacheEntry 는 캐시된 토큰 응답과 발급 시각을 담습니다.
+type cacheEntry struct {
+	IssuedAt int64           `json:"issued_at"`
+	Token    json.RawMessage `json:"token"`
+}
+
+// newTokenCache configures the cache from COPILOT_TOKEN_CACHE and its key variables; it returns nil when disabled.
+// newTokenCache 는 COPILOT_TOKEN_CACHE 와 키 환경 변수로 캐시를 구성하며, 비활성화 시 nil 을 반환합니다.
+func newTokenCache() (*tokenCache, error) {
+	path := envOrDefault("COPILOT_TOKEN_CACHE", "")
+	if path == "" {
+		return nil, nil
+	}
+	key := envOrDefault("COPILOT_TOKEN_CACHE_KEY", "")
+	if keyFile := envOrDefault("COPILOT_TOKEN_CACHE_KEY_FILE", ""); key == "" && keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read token cache key: %w", err)
+		}
+		key = strings.TrimSpace(string(data))
+	}
+	if key == "" {
+		return nil, errors.New("COPILOT_TOKEN_CACHE requires COPILOT_TOKEN_CACHE_KEY or COPILOT_TOKEN_CACHE_KEY_FILE")
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &tokenCache{path: path, aead: aead}, nil
+}
+
+// load returns the cached token for the OAuth token, or nil when there is none.
+// load 는 OAuth 토큰에 해당하는 캐시된 토큰을 반환하며, 없으면 nil 을 반환합니다.
+func (c *tokenCache) load(oauth string) *CopilotToken {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := c.read()
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("ignoring token cache %s: %v", c.path, err)
+		}
+		return nil
+	}
+	entry, ok := entries[cacheKey(oauth)]
+	if !ok {
+		return nil
+	}
+	token, err := parseCopilotToken(entry.Token, time.Unix(entry.IssuedAt, 0))
+	if err != nil {
+		return nil
+	}
+	return token
+}
+
+// store saves the token for the OAuth token and drops entries that have expired.
+// store 는 OAuth 토큰에 대한 토큰을 저장하고 만료된 항목을 제거합니다.
+func (c *tokenCache) store(oauth string, token *CopilotToken) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := c.read()
+	if err != nil {
+		entries = make(map[string]cacheEntry)
+	}
+	for key, entry := range entries {
+		if cached, err := parseCopilotToken(entry.Token, time.Unix(entry.IssuedAt, 0)); err != nil || !cached.Valid(0) {
+			delete(entries, key)
+		}
+	}
+	entries[cacheKey(oauth)] = cacheEntry{IssuedAt: token.IssuedAt.Unix(), Token: token.raw}
+
+	plain, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	return fileutil.WriteAtomic(c.path, c.aead.Seal(nonce, nonce, plain, nil))
+}
+
+// read decrypts and decodes the cache file.
+// read 는 캐시 파일을 복호화하고 디코딩합니다.
+func (c *tokenCache) read() (map[string]cacheEntry, error) {
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return nil, err
+	}
+	size := c.aead.NonceSize()
+	if len(data) < size {
+		return nil, errors.New("token cache is truncated")
+	}
+	plain, err := c.aead.Open(nil, data[:size], data[size:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt token cache: %w", err)
+	}
+	var entries map[string]cacheEntry
+	if err := json.Unmarshal(plain, &entries); err != nil {
+		return nil, fmt.Errorf("decode token cache: %w", err)
+	}
+	return entries, nil
+}
+
+// cacheKey derives the cache entry key from an OAuth token without storing the token itself.
+// cacheKey 는 OAuth 토큰 자체를 저장하지 않고 캐시 항목 키를 만듭니다.
+func cacheKey(oauth string) string {
+	sum := sha256.Sum256([]byte(oauth))
+	return hex.EncodeToString(sum[:])
+}
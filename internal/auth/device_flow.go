@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ilcm96/gh-copilot-proxy/internal/fileutil"
+)
+
+const (
+	defaultGitHubURL = "https://github.com"
+	defaultClientID  = "Iv1.b507a08c87ecfe98"
+)
+
+// deviceCode holds the response of the device authorization request.
+// deviceCode 는 디바이스 인증 요청의 응답을 담습니다.
+type deviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// accessTokenResponse holds a single poll result of the device flow.
+// accessTokenResponse 는 디바이스 플로우 폴링 결과 한 건을 담습니다.
+type accessTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	Error       string `json:"error"`
+	Description string `json:"error_description"`
+	Interval    int    `json:"interval"`
+}
+
+// Login runs the GitHub OAuth device flow and stores the issued token in apps.json.
+// Login 는 GitHub OAuth 디바이스 플로우를 수행하고 발급된 토큰을 apps.json 에 저장합니다.
+func Login(ctx context.Context, out io.Writer) error {
+	configDir, err := defaultConfigDir()
+	if err != nil {
+		return err
+	}
+	githubURL := strings.TrimRight(envOrDefault("COPILOT_GITHUB_URL", defaultGitHubURL), "/")
+	clientID := envOrDefault("COPILOT_CLIENT_ID", defaultClientID)
+
+	code, err := requestDeviceCode(ctx, githubURL, clientID)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Open %s and enter the code: %s\n", code.VerificationURI, code.UserCode)
+
+	token, err := pollAccessToken(ctx, githubURL, clientID, code)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(configDir, "github-copilot", "apps.json")
+	if err := saveOAuthToken(path, clientID, token); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Saved OAuth token to %s\n", path)
+	return nil
+}
+
+// requestDeviceCode asks GitHub for a new device and user code pair.
+// requestDeviceCode 는 GitHub 에 새 디바이스/사용자 코드 쌍을 요청합니다.
+func requestDeviceCode(ctx context.Context, githubURL, clientID string) (*deviceCode, error) {
+	form := url.Values{"client_id": {clientID}, "scope": {"read:user"}}
+	var code deviceCode
+	if err := postForm(ctx, githubURL+"/login/device/code", form, &code); err != nil {
+		return nil, fmt.Errorf("device code request: %w", err)
+	}
+	if code.DeviceCode == "" || code.UserCode == "" {
+		return nil, errors.New("device code response is missing codes")
+	}
+	return &code, nil
+}
+
+// pollAccessToken polls GitHub until the user authorizes the device or the code expires.
+// pollAccessToken 는 사용자가 디바이스를 승인하거나 코드가 만료될 때까지 GitHub 를 폴링합니다.
+func pollAccessToken(ctx context.Context, githubURL, clientID string, code *deviceCode) (string, error) {
+	interval := time.Duration(code.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	expiresIn := time.Duration(code.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = 15 * time.Minute
+	}
+	deadline := time.Now().Add(expiresIn)
+
+	form := url.Values{
+		"client_id":   {clientID},
+		"device_code": {code.DeviceCode},
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+	}
+	for {
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		if time.Now().After(deadline) {
+			return "", errors.New("device code expired")
+		}
+
+		var result accessTokenResponse
+		if err := postForm(ctx, githubURL+"/login/oauth/access_token", form, &result); err != nil {
+			return "", fmt.Errorf("access token request: %w", err)
+		}
+		switch result.Error {
+		case "":
+			if result.AccessToken == "" {
+				return "", errors.New("access token response is empty")
+			}
+			return result.AccessToken, nil
+		case "authorization_pending":
+		case "slow_down":
+			if result.Interval > 0 {
+				interval = time.Duration(result.Interval) * time.Second
+			} else {
+				interval += 5 * time.Second
+			}
+		default:
+			return "", fmt.Errorf("device flow failed: %s %s", result.Error, result.Description)
+		}
+	}
+}
+
+// postForm sends a form-encoded POST request and decodes the JSON response.
+// postForm 는 폼 인코딩된 POST 요청을 보내고 JSON 응답을 디코딩합니다.
+func postForm(ctx context.Context, target string, form url.Values, out any) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// saveOAuthToken merges the token into apps.json using the layout editor plugins write.
+// saveOAuthToken 는 에디터 플러그인이 쓰는 형식에 맞춰 토큰을 apps.json 에 병합합니다.
+func saveOAuthToken(path, clientID, token string) error {
+	apps := map[string]map[string]any{}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if len(strings.TrimSpace(string(data))) > 0 {
+			if err := json.Unmarshal(data, &apps); err != nil {
+				return fmt.Errorf("parse %s: %w", path, err)
+			}
+		}
+	case errors.Is(err, os.ErrNotExist):
+	default:
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+
+	apps["github.com:"+clientID] = map[string]any{
+		"oauth_token": token,
+		"githubAppId": clientID,
+	}
+	data, err = json.MarshalIndent(apps, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteAtomic(path, data)
+}
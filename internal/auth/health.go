@@ -0,0 +1,107 @@
+package auth
+
+import "time"
+
+// Health describes whether an account can currently serve requests.
+// Health 는 계정이 현재 요청을 처리할 수 있는지를 나타냅니다.
+type Health int
+
+const (
+	// HealthHealthy means the token is valid and the last refresh succeeded.
+	// HealthHealthy 는 토큰이 유효하고 마지막 갱신이 성공한 상태입니다.
+	HealthHealthy Health = iota
+	// HealthRefreshing means a refresh is in flight while the current token is still valid.
+	// HealthRefreshing 는 현재 토큰이 유효한 가운데 갱신이 진행 중인 상태입니다.
+	HealthRefreshing
+	// HealthDegraded means refreshes are failing but the current token is still valid.
+	// HealthDegraded 는 갱신이 실패하고 있지만 현재 토큰은 아직 유효한 상태입니다.
+	HealthDegraded
+	// HealthExpired means there is no valid token; requests cannot be served.
+	// HealthExpired 는 유효한 토큰이 없어 요청을 처리할 수 없는 상태입니다.
+	HealthExpired
+)
+
+// String returns the lower-case name of the state.
+// String 는 상태의 소문자 이름을 반환합니다.
+func (h Health) String() string {
+	switch h {
+	case HealthHealthy:
+		return "healthy"
+	case HealthRefreshing:
+		return "refreshing"
+	case HealthDegraded:
+		return "degraded"
+	default:
+		return "expired"
+	}
+}
+
+// Usable reports whether requests can be served in this state.
+// Usable 는 이 상태에서 요청을 처리할 수 있는지 확인합니다.
+func (h Health) Usable() bool {
+	return h != HealthExpired
+}
+
+// Health returns the current state of the account.
+// Health 는 계정의 현재 상태를 반환합니다.
+func (a *CopilotAuth) Health() Health {
+	if !a.Token().Valid(0) {
+		return HealthExpired
+	}
+	a.refreshMu.Lock()
+	refreshing := a.refreshing != nil
+	a.refreshMu.Unlock()
+	if refreshing {
+		return HealthRefreshing
+	}
+	if a.failing() {
+		return HealthDegraded
+	}
+	return HealthHealthy
+}
+
+// failing reports whether the last refresh attempt failed.
+// failing 는 마지막 갱신 시도가 실패했는지 확인합니다.
+func (a *CopilotAuth) failing() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.failures > 0
+}
+
+// AccountHealth is the state of a single pool account.
+// AccountHealth 는 풀에 속한 계정 하나의 상태입니다.
+type AccountHealth struct {
+	Name      string    `json:"name"`
+	State     string    `json:"state"`
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+}
+
+// Health returns the best state among the pool's accounts.
+// Health 는 풀에 속한 계정들 중 가장 좋은 상태를 반환합니다.
+func (p *Pool) Health() Health {
+	best := HealthExpired
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, account := range p.accounts {
+		if h := account.Health(); h < best {
+			best = h
+		}
+	}
+	return best
+}
+
+// Accounts reports the state of every account in the pool.
+// Accounts 는 풀에 속한 모든 계정의 상태를 반환합니다.
+func (p *Pool) Accounts() []AccountHealth {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	states := make([]AccountHealth, 0, len(p.accounts))
+	for _, account := range p.accounts {
+		state := AccountHealth{Name: account.Name(), State: account.Health().String()}
+		if token := account.Token(); token != nil {
+			state.ExpiresAt = token.ExpiresAt
+		}
+		states = append(states, state)
+	}
+	return states
+}
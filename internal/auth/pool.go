@@ -0,0 +1,226 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ilcm96/gh-copilot-proxy/internal/fswatch"
+)
+
+const watchInterval = 2 * time.Second
+
+// Pool manages several Copilot accounts and spreads requests across them.
+// Pool 는 여러 Copilot 계정을 관리하고 요청을 계정들에 분산합니다.
+type Pool struct {
+	mu       sync.RWMutex
+	accounts []*CopilotAuth
+	next     atomic.Uint64
+
+	sources []TokenSource
+	cache   *tokenCache
+	seq     int
+	users   userAccounts
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPool creates a Pool that manages one CopilotAuth per configured OAuth token.
+// NewPool 는 설정된 OAuth 토큰마다 CopilotAuth 를 하나씩 관리하는 Pool 을 생성합니다.
+func NewPool(parent context.Context) (*Pool, error) {
+	configDir, err := defaultConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	sources, err := defaultSources(configDir)
+	if err != nil {
+		return nil, err
+	}
+	cache, err := newTokenCache()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(parent)
+	return &Pool{
+		sources: sources,
+		cache:   cache,
+		ctx:     ctx,
+		cancel:  cancel,
+	}, nil
+}
+
+// Setup resolves the OAuth tokens from the configured sources and prepares an account for each of them.
+// Setup 는 설정된 소스에서 OAuth 토큰을 가져오고 토큰마다 계정을 준비합니다.
+func (p *Pool) Setup() error {
+	tokens, source, err := resolveOAuthTokens(p.ctx, p.sources)
+	if err != nil {
+		return err
+	}
+	log.Printf("using %d OAuth token(s) from %s", len(tokens), source.Name())
+
+	var accounts []*CopilotAuth
+	var errs []error
+	for _, token := range tokens {
+		account := p.newAccount(token)
+		if err := account.Setup(); err != nil {
+			log.Printf("%s: setup failed: %v", account.Name(), err)
+			account.Cleanup()
+			errs = append(errs, fmt.Errorf("%s: %w", account.Name(), err))
+			continue
+		}
+		accounts = append(accounts, account)
+	}
+	if len(accounts) == 0 {
+		return errors.Join(errs...)
+	}
+
+	p.mu.Lock()
+	p.accounts = accounts
+	p.mu.Unlock()
+	log.Printf("auth pool ready with %d of %d accounts", len(accounts), len(tokens))
+
+	if paths := watchPaths(p.sources); len(paths) > 0 {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			fswatch.Watch(p.ctx, watchInterval, paths, p.reload)
+		}()
+	}
+	return nil
+}
+
+// newAccount creates the next numbered account of the pool.
+// newAccount 는 풀의 다음 번호 계정을 생성합니다.
+func (p *Pool) newAccount(token string) *CopilotAuth {
+	p.seq++
+	return newAccount(p.ctx, fmt.Sprintf("account %d", p.seq), token, p.cache)
+}
+
+// reload re-resolves the token sources and applies added, changed and removed tokens to the pool.
+// reload 는 토큰 소스를 다시 읽어 추가·변경·삭제된 토큰을 풀에 반영합니다.
+//
+// A changed token is swapped into an account whose old token disappeared, so single-account setups keep their account.
+// 변경된 토큰은 기존 토큰이 사라진 계정에 교체되므로, 단일 계정 구성에서도 같은 계정이 유지됩니다.
+func (p *Pool) reload() {
+	tokens, source, err := resolveOAuthTokens(p.ctx, p.sources)
+	if err != nil {
+		log.Printf("token reload skipped: %v", err)
+		return
+	}
+
+	p.mu.RLock()
+	current := append([]*CopilotAuth(nil), p.accounts...)
+	p.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(tokens))
+	for _, token := range tokens {
+		wanted[token] = true
+	}
+	kept := make(map[string]*CopilotAuth)
+	var stale []*CopilotAuth
+	for _, account := range current {
+		if token := account.oauth(); wanted[token] {
+			kept[token] = account
+		} else {
+			stale = append(stale, account)
+		}
+	}
+	if len(stale) == 0 && len(kept) == len(tokens) {
+		return
+	}
+	log.Printf("OAuth tokens changed in %s, reloading", source.Name())
+
+	var accounts []*CopilotAuth
+	for _, token := range tokens {
+		if account, ok := kept[token]; ok {
+			accounts = append(accounts, account)
+			continue
+		}
+		if len(stale) > 0 {
+			account := stale[0]
+			stale = stale[1:]
+			log.Printf("%s: OAuth token replaced (%s -> %s)", account.Name(), fingerprint(account.oauth()), fingerprint(token))
+			if err := account.SetOAuthToken(token); err != nil {
+				log.Printf("%s: refresh with new OAuth token failed: %v", account.Name(), err)
+			}
+			accounts = append(accounts, account)
+			continue
+		}
+		account := p.newAccount(token)
+		if err := account.Setup(); err != nil {
+			log.Printf("%s: setup failed: %v", account.Name(), err)
+			account.Cleanup()
+			continue
+		}
+		log.Printf("%s: added with OAuth token %s", account.Name(), fingerprint(token))
+		accounts = append(accounts, account)
+	}
+
+	p.mu.Lock()
+	p.accounts = accounts
+	p.mu.Unlock()
+
+	for _, account := range stale {
+		log.Printf("%s: removed (OAuth token %s)", account.Name(), fingerprint(account.oauth()))
+		account.Cleanup()
+	}
+}
+
+// Cleanup stops the background work of every account.
+// Cleanup 는 모든 계정의 백그라운드 작업을 정리합니다.
+func (p *Pool) Cleanup() {
+	p.cancel()
+	p.wg.Wait()
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, account := range p.accounts {
+		account.Cleanup()
+	}
+}
+
+// Len returns the number of accounts in the pool.
+// Len 는 풀에 속한 계정 수를 반환합니다.
+func (p *Pool) Len() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.accounts)
+}
+
+// Acquire picks the next account round-robin, skipping throttled accounts and those in exclude.
+// Acquire 는 제한 중이거나 exclude 에 포함된 계정을 건너뛰며 라운드 로빈으로 다음 계정을 고릅니다.
+//
+// When every remaining account is throttled, the one whose throttle ends first is returned.
+// 남은 계정이 모두 제한 중이면 제한이 가장 먼저 풀리는 계정을 반환합니다.
+func (p *Pool) Acquire(exclude map[*CopilotAuth]bool) *CopilotAuth {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	n := len(p.accounts)
+	if n == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	start := int(p.next.Add(1) - 1)
+	var fallback *CopilotAuth
+	var fallbackUntil time.Time
+	for i := 0; i < n; i++ {
+		account := p.accounts[(start+i)%n]
+		if exclude[account] || !account.Token().Valid(0) {
+			continue
+		}
+		until := account.throttledAt()
+		if !until.After(now) {
+			return account
+		}
+		if fallback == nil || until.Before(fallbackUntil) {
+			fallback, fallbackUntil = account, until
+		}
+	}
+	return fallback
+}
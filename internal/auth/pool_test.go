@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// testAccount returns an account holding a Copilot token that expires in ttl; a negative ttl gives an expired token.
+func testAccount(name string, ttl time.Duration) *CopilotAuth {
+	return &CopilotAuth{name: name, githubToken: &CopilotToken{Token: name, ExpiresAt: time.Now().Add(ttl)}}
+}
+
+// names returns the account names, with "" for nil.
+func names(accounts ...*CopilotAuth) []string {
+	out := make([]string, len(accounts))
+	for i, account := range accounts {
+		if account != nil {
+			out[i] = account.Name()
+		}
+	}
+	return out
+}
+
+func TestPoolAcquire(t *testing.T) {
+	tests := []struct {
+		name     string
+		accounts func() []*CopilotAuth
+		exclude  []int
+		calls    int
+		want     []string
+	}{
+		{
+			name:  "empty pool",
+			calls: 1,
+			want:  []string{""},
+			accounts: func() []*CopilotAuth {
+				return nil
+			},
+		},
+		{
+			name:  "round robin",
+			calls: 4,
+			want:  []string{"a", "b", "c", "a"},
+			accounts: func() []*CopilotAuth {
+				return []*CopilotAuth{testAccount("a", time.Hour), testAccount("b", time.Hour), testAccount("c", time.Hour)}
+			},
+		},
+		{
+			name:  "throttled account is skipped",
+			calls: 3,
+			want:  []string{"a", "c", "c"},
+			accounts: func() []*CopilotAuth {
+				b := testAccount("b", time.Hour)
+				b.MarkThrottled(time.Minute)
+				return []*CopilotAuth{testAccount("a", time.Hour), b, testAccount("c", time.Hour)}
+			},
+		},
+		{
+			name:  "expired token is skipped",
+			calls: 2,
+			want:  []string{"b", "b"},
+			accounts: func() []*CopilotAuth {
+				return []*CopilotAuth{testAccount("a", -time.Minute), testAccount("b", time.Hour)}
+			},
+		},
+		{
+			name:  "all throttled returns the one released first",
+			calls: 2,
+			want:  []string{"b", "b"},
+			accounts: func() []*CopilotAuth {
+				a, b := testAccount("a", time.Hour), testAccount("b", time.Hour)
+				a.MarkThrottled(time.Hour)
+				b.MarkThrottled(time.Minute)
+				return []*CopilotAuth{a, b}
+			},
+		},
+		{
+			name:    "excluded accounts are skipped",
+			exclude: []int{0, 1},
+			calls:   2,
+			want:    []string{"c", "c"},
+			accounts: func() []*CopilotAuth {
+				return []*CopilotAuth{testAccount("a", time.Hour), testAccount("b", time.Hour), testAccount("c", time.Hour)}
+			},
+		},
+		{
+			name:    "nothing left after excluding every account",
+			exclude: []int{0, 1},
+			calls:   1,
+			want:    []string{""},
+			accounts: func() []*CopilotAuth {
+				return []*CopilotAuth{testAccount("a", time.Hour), testAccount("b", time.Hour)}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pool{accounts: tt.accounts()}
+			exclude := make(map[*CopilotAuth]bool)
+			for _, i := range tt.exclude {
+				exclude[p.accounts[i]] = true
+			}
+			var got []*CopilotAuth
+			for range tt.calls {
+				got = append(got, p.Acquire(exclude))
+			}
+			if gotNames := names(got...); !slices.Equal(gotNames, tt.want) {
+				t.Errorf("Acquire() = %q, want %q", gotNames, tt.want)
+			}
+		})
+	}
+}
+
+// TestPoolAcquireFailover walks the pool the way the proxy fails over:
+// every attempt excludes the accounts already tried until none is left.
+func TestPoolAcquireFailover(t *testing.T) {
+	a, b, c := testAccount("a", time.Hour), testAccount("b", time.Hour), testAccount("c", time.Hour)
+	p := &Pool{accounts: []*CopilotAuth{a, b, c}}
+
+	tried := make(map[*CopilotAuth]bool)
+	var order []*CopilotAuth
+	for {
+		account := p.Acquire(tried)
+		if account == nil {
+			break
+		}
+		if tried[account] {
+			t.Fatalf("Acquire() returned %s twice", account.Name())
+		}
+		tried[account] = true
+		order = append(order, account)
+		// The upstream answered 429, so the account is throttled before moving on.
+		account.MarkThrottled(time.Minute)
+	}
+	if got := names(order...); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("failover order = %q, want every account once", got)
+	}
+
+	// With every account throttled, a fresh request still gets the one released first.
+	b.MarkThrottled(time.Second)
+	if got := p.Acquire(nil); got != b {
+		t.Errorf("Acquire() with all throttled = %v, want b", names(got))
+	}
+}
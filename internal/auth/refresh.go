@@ -3,62 +3,101 @@ package auth
 import (
 	"encoding/json"
 	"log"
+	"math/rand/v2"
 	"strconv"
 	"time"
 )
 
+// refreshCall tracks a token refresh that concurrent callers wait on.
+// refreshCall 는 동시 호출자들이 기다리는 토큰 갱신 작업을 추적합니다.
+type refreshCall struct {
+	done chan struct{}
+	ok   bool
+	err  error
+}
+
 // runRefreshTimer monitors token expiry and attempts periodic refreshes.
 // runRefreshTimer 는 토큰 만료 시간을 감시하며 주기적으로 갱신을 시도합니다.
 func (a *CopilotAuth) runRefreshTimer() {
 	defer a.wg.Done()
 	for {
 		select {
+		case <-time.After(a.nextRefreshDelay()):
 		case <-a.ctx.Done():
 			return
-		default:
 		}
-		if _, err := a.RefreshToken(false); err != nil {
-			log.Printf("token refresh error: %v", err)
+		if err := a.scheduledRefresh(); err != nil {
+			log.Printf("%s: token refresh error: %v", a.name, err)
 		}
+	}
+}
+
+// scheduledRefresh runs one timer-driven refresh, forced once refresh_in is due or while refreshes are failing.
+// scheduledRefresh 는 타이머에 따른 갱신을 한 번 실행하며, refresh_in 기한이 되었거나 갱신이 실패하는 동안에는 강제로 갱신합니다.
+//
+// A forced refresh can fail while the token is still valid, so the retry must be forced too or the account stays degraded.
+// 토큰이 유효한 동안에도 강제 갱신은 실패할 수 있으므로, 재시도도 강제해야 계정이 degraded 상태에 머물지 않습니다.
+func (a *CopilotAuth) scheduledRefresh() error {
+	_, err := a.RefreshToken(a.refreshDue() || a.failing())
+	return err
+}
 
-		sleep := time.Minute
-		a.mu.RLock()
-		if a.githubToken != nil {
-			if expires, ok := extractTimestamp(a.githubToken["expires_at"]); ok {
-				refreshAt := time.Unix(int64(expires), 0).Add(-2 * time.Minute)
-				if refreshAt.Before(time.Now()) {
-					sleep = 5 * time.Second
-				} else {
-					sleep = time.Until(refreshAt)
-					if sleep < 5*time.Second {
-						sleep = 5 * time.Second
-					}
-				}
+// nextRefreshDelay returns how long to wait before the next refresh attempt.
+// nextRefreshDelay 는 다음 갱신 시도까지 기다릴 시간을 반환합니다.
+//
+// After failures it backs off exponentially with jitter; otherwise it follows the token schedule.
+// 실패한 뒤에는 지터를 더한 지수 백오프를 따르고, 그 외에는 토큰 일정에 맞춥니다.
+func (a *CopilotAuth) nextRefreshDelay() time.Duration {
+	a.mu.RLock()
+	failures := a.failures
+	a.mu.RUnlock()
+	if failures > 0 {
+		return backoff(failures)
+	}
+
+	sleep := time.Minute
+	if token := a.Token(); token != nil {
+		refreshAt := token.RefreshAt()
+		if refreshAt.Before(time.Now()) {
+			sleep = 5 * time.Second
+		} else {
+			sleep = time.Until(refreshAt)
+			if sleep < 5*time.Second {
+				sleep = 5 * time.Second
 			}
 		}
-		a.mu.RUnlock()
+	}
+	return sleep
+}
 
-		select {
-		case <-time.After(sleep):
-		case <-a.ctx.Done():
-			return
-		}
+// backoff returns an exponentially growing delay for the given failure count, capped and jittered.
+// backoff 는 실패 횟수에 따라 지수적으로 늘어나는 지연 시간을 상한과 지터를 적용해 반환합니다.
+func backoff(failures int) time.Duration {
+	const (
+		base     = 5 * time.Second
+		maxDelay = 5 * time.Minute
+	)
+	delay := maxDelay
+	if failures < 8 {
+		delay = min(base<<(failures-1), maxDelay)
 	}
+	// Full jitter over the upper half keeps retries from several instances apart.
+	// 상위 절반 구간의 지터로 여러 인스턴스의 재시도가 겹치지 않게 합니다.
+	half := delay / 2
+	return half + rand.N(half+1)
 }
 
 // isTokenValid checks whether the cached token is still valid.
 // isTokenValid 는 현재 캐시된 토큰이 아직 유효한지 검사합니다.
 func (a *CopilotAuth) isTokenValid() bool {
-	a.mu.RLock()
-	defer a.mu.RUnlock()
-	if a.githubToken == nil {
-		return false
-	}
-	expires, ok := extractTimestamp(a.githubToken["expires_at"])
-	if !ok {
-		return false
-	}
-	return float64(time.Now().Unix()+120) < expires
+	return a.Token().Valid(2 * time.Minute)
+}
+
+// refreshDue reports whether the token has reached its refresh_in deadline while still valid.
+// refreshDue 는 토큰이 아직 유효하지만 refresh_in 기한에 도달했는지 확인합니다.
+func (a *CopilotAuth) refreshDue() bool {
+	token := a.Token()
+	return token != nil && token.RefreshIn > 0 && !time.Now().Before(token.RefreshAt())
 }
 
 // extractTimestamp converts various timestamp representations into Unix seconds.
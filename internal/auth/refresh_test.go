@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScheduledRefreshRecoversFromFailure(t *testing.T) {
+	var fail atomic.Bool
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if fail.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, `{"token":"fresh","expires_at":%d,"refresh_in":1500}`, time.Now().Add(30*time.Minute).Unix())
+	}))
+	defer server.Close()
+
+	a := newAccount(context.Background(), "account 1", "oauth", nil)
+	defer a.Cleanup()
+	a.tokenURL = server.URL
+	a.githubToken = &CopilotToken{Token: "current", IssuedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour), RefreshIn: time.Hour}
+
+	// Nothing is due and nothing failed, so the timer leaves the valid token alone.
+	if err := a.scheduledRefresh(); err != nil {
+		t.Fatalf("scheduledRefresh() error = %v", err)
+	}
+	if n := calls.Load(); n != 0 {
+		t.Fatalf("scheduledRefresh() with a valid token made %d request(s), want 0", n)
+	}
+
+	// A forced refresh after an upstream 401 fails while the token is still valid.
+	fail.Store(true)
+	if err := a.RefreshStale("current"); err == nil {
+		t.Fatal("RefreshStale() returned no error")
+	}
+	if h := a.Health(); h != HealthDegraded {
+		t.Fatalf("Health() after a failed refresh = %s, want degraded", h)
+	}
+	if err := a.scheduledRefresh(); err == nil {
+		t.Fatal("scheduledRefresh() while upstream fails returned no error")
+	}
+	if h := a.Health(); h != HealthDegraded {
+		t.Fatalf("Health() after a failed retry = %s, want degraded", h)
+	}
+
+	// Once upstream recovers, the next timer run refreshes although the old token is still valid.
+	fail.Store(false)
+	before := calls.Load()
+	if err := a.scheduledRefresh(); err != nil {
+		t.Fatalf("scheduledRefresh() error = %v", err)
+	}
+	if calls.Load() == before {
+		t.Fatal("scheduledRefresh() while degraded did not refresh")
+	}
+	if h := a.Health(); h != HealthHealthy {
+		t.Errorf("Health() after recovery = %s, want healthy", h)
+	}
+	if got := a.BearerToken(); got != "fresh" {
+		t.Errorf("BearerToken() = %q, want fresh", got)
+	}
+	if d := a.nextRefreshDelay(); d < 10*time.Minute {
+		t.Errorf("nextRefreshDelay() after recovery = %s, want the token schedule", d)
+	}
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CopilotToken is the parsed response of the Copilot token endpoint.
+// CopilotToken 는 Copilot 토큰 엔드포인트 응답을 파싱한 결과입니다.
+type CopilotToken struct {
+	Token       string
+	IssuedAt    time.Time
+	ExpiresAt   time.Time
+	RefreshIn   time.Duration
+	SKU         string
+	ChatEnabled bool
+	Endpoints   TokenEndpoints
+	// Features holds every boolean flag in the response, e.g. "chat_enabled" or "code_review_enabled".
+	// Features 는 응답의 모든 불리언 플래그를 담습니다. 예: "chat_enabled", "code_review_enabled"
+	Features map[string]bool
+
+	raw []byte
+}
+
+// TokenEndpoints lists the per-plan hosts advertised by the Copilot token.
+// TokenEndpoints 는 Copilot 토큰이 알려 주는 요금제별 호스트 목록입니다.
+type TokenEndpoints struct {
+	API           string `json:"api"`
+	OriginTracker string `json:"origin-tracker"`
+	Proxy         string `json:"proxy"`
+	Telemetry     string `json:"telemetry"`
+}
+
+// parseCopilotToken decodes a token endpoint response issued at issuedAt.
+// parseCopilotToken 는 issuedAt 시각에 발급된 토큰 엔드포인트 응답을 디코딩합니다.
+func parseCopilotToken(data []byte, issuedAt time.Time) (*CopilotToken, error) {
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("decode token response: %w", err)
+	}
+
+	token := &CopilotToken{
+		IssuedAt:    issuedAt,
+		ChatEnabled: true,
+		Features:    make(map[string]bool),
+		raw:         data,
+	}
+	token.Token, _ = fields["token"].(string)
+	if token.Token == "" {
+		return nil, errors.New("token response has no token")
+	}
+	if expires, ok := extractTimestamp(fields["expires_at"]); ok {
+		token.ExpiresAt = time.Unix(int64(expires), 0)
+	}
+	if refreshIn, ok := extractTimestamp(fields["refresh_in"]); ok && refreshIn > 0 {
+		token.RefreshIn = time.Duration(refreshIn) * time.Second
+	}
+	token.SKU, _ = fields["sku"].(string)
+	for key, value := range fields {
+		if flag, ok := value.(bool); ok {
+			token.Features[key] = flag
+		}
+	}
+	if enabled, ok := token.Features["chat_enabled"]; ok {
+		token.ChatEnabled = enabled
+	}
+
+	var envelope struct {
+		Endpoints TokenEndpoints `json:"endpoints"`
+	}
+	if err := json.Unmarshal(data, &envelope); err == nil {
+		token.Endpoints = envelope.Endpoints
+	}
+	return token, nil
+}
+
+// Valid reports whether the token stays usable for at least the given margin.
+// Valid 는 토큰이 최소 margin 동안 더 사용 가능한지 확인합니다.
+func (t *CopilotToken) Valid(margin time.Duration) bool {
+	if t == nil || t.ExpiresAt.IsZero() {
+		return false
+	}
+	return time.Now().Add(margin).Before(t.ExpiresAt)
+}
+
+// RefreshAt returns when the token should be refreshed, preferring refresh_in over expires_at.
+// RefreshAt 는 토큰을 갱신할 시각을 반환하며, expires_at 보다 refresh_in 을 우선합니다.
+func (t *CopilotToken) RefreshAt() time.Time {
+	if t.RefreshIn > 0 {
+		return t.IssuedAt.Add(t.RefreshIn)
+	}
+	if t.ExpiresAt.IsZero() {
+		return time.Now().Add(time.Minute)
+	}
+	return t.ExpiresAt.Add(-2 * time.Minute)
+}
@@ -2,49 +2,113 @@ package auth
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 )
 
-// getOAuthToken retrieves the OAuth token from environment variables or GitHub config files.
-// getOAuthToken 는 환경 변수 또는 GitHub 설정 파일에서 OAuth 토큰을 검색합니다.
-func (a *CopilotAuth) getOAuthToken() (string, error) {
-	if token := strings.TrimSpace(os.Getenv("COPILOT_OAUTH_TOKEN")); token != "" {
-		return token, nil
+// fileSource reads OAuth tokens from a plain file such as a Docker or Kubernetes secret.
+// fileSource 는 Docker/Kubernetes 시크릿 같은 일반 파일에서 OAuth 토큰을 읽습니다.
+type fileSource struct {
+	path string
+}
+
+// Name describes the source for logs.
+// Name 는 로그에 표시할 소스 설명을 반환합니다.
+func (s *fileSource) Name() string {
+	return "file " + s.path
+}
+
+// Tokens returns one token per non-empty line of the file.
+// Tokens 는 파일의 비어 있지 않은 줄마다 토큰 하나를 반환합니다.
+func (s *fileSource) Tokens(context.Context) ([]string, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNoToken
+		}
+		return nil, fmt.Errorf("read %s: %w", s.path, err)
+	}
+	tokens := splitTokens(string(data), "\n")
+	if len(tokens) == 0 {
+		return nil, ErrNoToken
 	}
+	return tokens, nil
+}
+
+// configSource reads the OAuth token that Copilot editor plugins store in apps.json or hosts.json.
+// configSource 는 Copilot 에디터 플러그인이 apps.json 또는 hosts.json 에 저장한 OAuth 토큰을 읽습니다.
+type configSource struct {
+	configDir string
+}
 
-	files := []string{"apps.json", "hosts.json"}
-	for _, name := range files {
-		path := filepath.Join(a.configDir, "github-copilot", name)
+// Name describes the source for logs.
+// Name 는 로그에 표시할 소스 설명을 반환합니다.
+func (s *configSource) Name() string {
+	return "github-copilot config"
+}
+
+// paths returns the config files in lookup order.
+// paths 는 검색 순서대로 설정 파일 경로를 반환합니다.
+func (s *configSource) paths() []string {
+	return []string{
+		filepath.Join(s.configDir, "github-copilot", "apps.json"),
+		filepath.Join(s.configDir, "github-copilot", "hosts.json"),
+	}
+}
+
+// Tokens returns the first github.com OAuth token found in the config files, in the order of configHosts.
+// Tokens 는 설정 파일에서 configHosts 순서로 처음 발견한 github.com OAuth 토큰을 반환합니다.
+func (s *configSource) Tokens(context.Context) ([]string, error) {
+	for _, path := range s.paths() {
 		data, err := os.ReadFile(path)
 		if err != nil {
 			if errors.Is(err, os.ErrNotExist) {
 				continue
 			}
-			return "", fmt.Errorf("read %s: %w", path, err)
+			return nil, fmt.Errorf("read %s: %w", path, err)
 		}
 		if len(bytes.TrimSpace(data)) == 0 {
 			continue
 		}
 		var hosts map[string]map[string]any
 		if err := json.Unmarshal(data, &hosts); err != nil {
-			return "", fmt.Errorf("parse %s: %w", path, err)
+			return nil, fmt.Errorf("parse %s: %w", path, err)
 		}
-		for host, entry := range hosts {
-			if !strings.Contains(host, "github.com") {
-				continue
-			}
-			if entry == nil {
-				continue
-			}
-			if token, ok := entry["oauth_token"].(string); ok && token != "" {
-				return token, nil
+		for _, host := range configHosts(hosts) {
+			if token, ok := hosts[host]["oauth_token"].(string); ok && token != "" {
+				return []string{token}, nil
 			}
 		}
 	}
-	return "", errors.New("GitHub OAuth token not found")
+	return nil, ErrNoToken
+}
+
+// configHosts returns the github.com entries of a config file in a stable order:
+// the entry the device flow writes for the login client ID first, then the rest sorted by key.
+// configHosts 는 설정 파일의 github.com 항목을 일정한 순서로 반환합니다.
+// 디바이스 플로우가 로그인 클라이언트 ID 로 쓴 항목이 먼저 오고, 나머지는 키 순서로 정렬합니다.
+func configHosts(hosts map[string]map[string]any) []string {
+	preferred := "github.com:" + envOrDefault("COPILOT_CLIENT_ID", defaultClientID)
+	var keys []string
+	for host, entry := range hosts {
+		if strings.Contains(host, "github.com") && entry != nil {
+			keys = append(keys, host)
+		}
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		switch {
+		case a == preferred:
+			return -1
+		case b == preferred:
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+	return keys
 }
@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const defaultTokenSources = "env,config"
+
+// ErrNoToken is returned by a TokenSource that currently has no token to offer.
+// ErrNoToken 는 TokenSource 가 현재 제공할 토큰이 없을 때 반환됩니다.
+var ErrNoToken = errors.New("GitHub OAuth token not found")
+
+// TokenSource supplies GitHub OAuth tokens; several tokens form an account pool.
+// TokenSource 는 GitHub OAuth 토큰을 제공하며, 여러 토큰은 계정 풀을 구성합니다.
+type TokenSource interface {
+	// Name describes the source for logs without revealing any secret.
+	// Name 는 비밀 값을 드러내지 않고 로그에 표시할 소스 설명을 반환합니다.
+	Name() string
+	// Tokens returns the tokens offered by the source, or ErrNoToken when it has none.
+	// Tokens 는 소스가 제공하는 토큰 목록을 반환하며, 없으면 ErrNoToken 을 반환합니다.
+	Tokens(ctx context.Context) ([]string, error)
+}
+
+// parseTokenSources builds the ordered source list from a spec such as "env,file:/run/secrets/copilot,command:gh auth token,config".
+// parseTokenSources 는 "env,file:/run/secrets/copilot,command:gh auth token,config" 같은 설정으로 순서가 있는 소스 목록을 만듭니다.
+func parseTokenSources(spec, configDir string) ([]TokenSource, error) {
+	var sources []TokenSource
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kind, arg, _ := strings.Cut(entry, ":")
+		arg = strings.TrimSpace(arg)
+		switch strings.ToLower(strings.TrimSpace(kind)) {
+		case "env":
+			if arg == "" {
+				arg = "COPILOT_OAUTH_TOKEN"
+			}
+			sources = append(sources, &envSource{name: arg})
+		case "file":
+			if arg == "" {
+				return nil, fmt.Errorf("token source %q: missing path", entry)
+			}
+			sources = append(sources, &fileSource{path: arg})
+		case "config":
+			sources = append(sources, &configSource{configDir: configDir})
+		case "command":
+			args := strings.Fields(arg)
+			if len(args) == 0 {
+				return nil, fmt.Errorf("token source %q: missing command", entry)
+			}
+			sources = append(sources, &commandSource{args: args})
+		default:
+			return nil, fmt.Errorf("unknown token source %q", entry)
+		}
+	}
+	if len(sources) == 0 {
+		return nil, errors.New("no token sources configured")
+	}
+	return sources, nil
+}
+
+// defaultSources returns the sources selected by COPILOT_TOKEN_SOURCES.
+// defaultSources 는 COPILOT_TOKEN_SOURCES 로 선택된 소스 목록을 반환합니다.
+func defaultSources(configDir string) ([]TokenSource, error) {
+	return parseTokenSources(envOrDefault("COPILOT_TOKEN_SOURCES", defaultTokenSources), configDir)
+}
+
+// watchPaths returns the files backing the sources, for change detection.
+// watchPaths 는 변경 감지를 위해 소스가 읽는 파일 목록을 반환합니다.
+func watchPaths(sources []TokenSource) []string {
+	var paths []string
+	for _, source := range sources {
+		switch s := source.(type) {
+		case *fileSource:
+			paths = append(paths, s.path)
+		case *configSource:
+			paths = append(paths, s.paths()...)
+		}
+	}
+	return paths
+}
+
+// resolveOAuthTokens returns the tokens of the first source in order that has any.
+// resolveOAuthTokens 는 순서대로 소스를 확인해 토큰이 있는 첫 소스의 토큰들을 반환합니다.
+func resolveOAuthTokens(ctx context.Context, sources []TokenSource) ([]string, TokenSource, error) {
+	for _, source := range sources {
+		tokens, err := source.Tokens(ctx)
+		if errors.Is(err, ErrNoToken) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", source.Name(), err)
+		}
+		return tokens, source, nil
+	}
+	return nil, nil, ErrNoToken
+}
+
+// envSource reads comma-separated OAuth tokens from an environment variable.
+// envSource 는 환경 변수에서 쉼표로 구분된 OAuth 토큰을 읽습니다.
+type envSource struct {
+	name string
+}
+
+// Name describes the source for logs.
+// Name 는 로그에 표시할 소스 설명을 반환합니다.
+func (s *envSource) Name() string {
+	return "env " + s.name
+}
+
+// Tokens returns the tokens listed in the environment variable.
+// Tokens 는 환경 변수에 나열된 토큰들을 반환합니다.
+func (s *envSource) Tokens(context.Context) ([]string, error) {
+	tokens := splitTokens(os.Getenv(s.name), ",")
+	if len(tokens) == 0 {
+		return nil, ErrNoToken
+	}
+	return tokens, nil
+}
+
+// commandSource runs a credential helper such as `gh auth token` and reads tokens from its stdout.
+// commandSource 는 `gh auth token` 같은 자격 증명 도우미를 실행하고 표준 출력에서 토큰을 읽습니다.
+type commandSource struct {
+	args []string
+}
+
+// Name describes the source for logs.
+// Name 는 로그에 표시할 소스 설명을 반환합니다.
+func (s *commandSource) Name() string {
+	return "command " + s.args[0]
+}
+
+// Tokens runs the command and returns one token per non-empty output line.
+// Tokens 는 명령을 실행하고 비어 있지 않은 출력 줄마다 토큰 하나를 반환합니다.
+func (s *commandSource) Tokens(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, s.args[0], s.args[1:]...)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("run %s: %w", s.args[0], err)
+	}
+	tokens := splitTokens(string(out), "\n")
+	if len(tokens) == 0 {
+		return nil, ErrNoToken
+	}
+	return tokens, nil
+}
+
+// splitTokens splits s by sep, trimming blanks and dropping duplicates.
+// splitTokens 는 s 를 sep 으로 나누고 공백과 중복을 제거합니다.
+func splitTokens(s, sep string) []string {
+	var tokens []string
+	seen := make(map[string]struct{})
+	for _, token := range strings.Split(s, sep) {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+		if _, dup := seen[token]; dup {
+			continue
+		}
+		seen[token] = struct{}{}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// fingerprint returns a short, non-reversible identifier of a secret for logs.
+// fingerprint 는 로그에 사용할 수 있도록 비밀 값의 짧고 되돌릴 수 없는 식별자를 반환합니다.
+func fingerprint(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:4])
+}
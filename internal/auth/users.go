@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	userIdleTimeout   = time.Hour
+	userSweepInterval = 5 * time.Minute
+)
+
+// userAccounts caches Copilot accounts created from OAuth tokens supplied by clients.
+// userAccounts 는 클라이언트가 제공한 OAuth 토큰으로 만든 Copilot 계정을 캐시합니다.
+type userAccounts struct {
+	mu        sync.Mutex
+	accounts  map[string]*userAccount
+	lastSweep time.Time
+}
+
+// userAccount is a cached per-user account and the time it was last used.
+// userAccount 는 캐시된 사용자별 계정과 마지막 사용 시각입니다.
+type userAccount struct {
+	account  *CopilotAuth
+	lastUsed time.Time
+}
+
+// ForUser returns an account for a client-supplied GitHub OAuth token, exchanging it for a Copilot token when needed.
+// ForUser 는 클라이언트가 제공한 GitHub OAuth 토큰에 대한 계정을 반환하며, 필요하면 Copilot 토큰으로 교환합니다.
+//
+// Each user's token is refreshed on demand with its own expiry and is never written to the disk cache.
+// 사용자 토큰은 각자의 만료 시각에 맞춰 요청 시 갱신되며 디스크 캐시에는 기록되지 않습니다.
+func (p *Pool) ForUser(oauth string) (*CopilotAuth, error) {
+	key := cacheKey(oauth)
+	now := time.Now()
+
+	p.users.mu.Lock()
+	if p.users.accounts == nil {
+		p.users.accounts = make(map[string]*userAccount)
+	}
+	if now.Sub(p.users.lastSweep) > userSweepInterval {
+		p.users.sweep(now)
+	}
+	entry, ok := p.users.accounts[key]
+	if !ok {
+		entry = &userAccount{account: newAccount(p.ctx, "user "+fingerprint(oauth), oauth, nil)}
+		p.users.accounts[key] = entry
+	}
+	entry.lastUsed = now
+	p.users.mu.Unlock()
+
+	ok, err := entry.account.RefreshToken(false)
+	if err == nil && !ok {
+		err = errors.New("token refresh failed")
+	}
+	if err != nil {
+		p.users.mu.Lock()
+		if p.users.accounts[key] == entry {
+			delete(p.users.accounts, key)
+		}
+		p.users.mu.Unlock()
+		return nil, fmt.Errorf("%s: %w", entry.account.Name(), err)
+	}
+	return entry.account, nil
+}
+
+// sweep drops accounts that have been idle longer than userIdleTimeout; the caller holds mu.
+// sweep 는 userIdleTimeout 보다 오래 사용되지 않은 계정을 제거하며, 호출자가 mu 를 잡고 있어야 합니다.
+func (u *userAccounts) sweep(now time.Time) {
+	u.lastSweep = now
+	for key, entry := range u.accounts {
+		if now.Sub(entry.lastUsed) > userIdleTimeout {
+			log.Printf("%s: evicted after %s idle", entry.account.Name(), userIdleTimeout)
+			entry.account.Cleanup()
+			delete(u.accounts, key)
+		}
+	}
+}
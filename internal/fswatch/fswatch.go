@@ -0,0 +1,66 @@
+package fswatch
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// fileState is the part of a file's metadata used to detect changes.
+// fileState 는 변경 감지에 사용하는 파일 메타데이터 일부입니다.
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+// equal reports whether two states describe the same file version.
+// equal 는 두 상태가 같은 파일 버전을 가리키는지 확인합니다.
+func (s fileState) equal(o fileState) bool {
+	return s.exists == o.exists && s.size == o.size && s.modTime.Equal(o.modTime)
+}
+
+// Watch polls paths every interval and calls onChange when any of them is created, modified or removed.
+// Watch 는 interval 마다 paths 를 확인하고, 파일이 생성·수정·삭제되면 onChange 를 호출합니다.
+//
+// It blocks until ctx is cancelled.
+// ctx 가 취소될 때까지 반환하지 않습니다.
+func Watch(ctx context.Context, interval time.Duration, paths []string, onChange func()) {
+	states := snapshot(paths)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		current := snapshot(paths)
+		changed := false
+		for path, state := range current {
+			if !states[path].equal(state) {
+				changed = true
+				break
+			}
+		}
+		states = current
+		if changed {
+			onChange()
+		}
+	}
+}
+
+// snapshot records the current state of every path.
+// snapshot 는 모든 경로의 현재 상태를 기록합니다.
+func snapshot(paths []string) map[string]fileState {
+	states := make(map[string]fileState, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			states[path] = fileState{}
+			continue
+		}
+		states[path] = fileState{exists: true, size: info.Size(), modTime: info.ModTime()}
+	}
+	return states
+}
@@ -0,0 +1,72 @@
+package httpx
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ParseCIDRs parses a comma-separated list of CIDR prefixes; bare addresses are treated as single hosts.
+// ParseCIDRs 는 쉼표로 구분한 CIDR 목록을 파싱하며, 주소만 적으면 단일 호스트로 봅니다.
+func ParseCIDRs(spec string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid address %q: %w", entry, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", entry, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// WithAllowlist rejects requests whose peer address is outside the given prefixes.
+// WithAllowlist 는 접속한 주소가 주어진 대역 밖에 있는 요청을 거부합니다.
+//
+// Only the TCP peer address is checked; forwarding headers such as X-Forwarded-For are not trusted.
+// TCP 상대 주소만 확인하며, X-Forwarded-For 같은 전달 헤더는 신뢰하지 않습니다.
+func WithAllowlist(prefixes []netip.Prefix, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !allowed(prefixes, r.RemoteAddr) {
+			log.Printf("rejected request from %s: address not allowed", r.RemoteAddr)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowed reports whether the host part of remoteAddr falls inside one of the prefixes.
+// allowed 는 remoteAddr 의 호스트 부분이 대역 중 하나에 속하는지 확인합니다.
+func allowed(prefixes []netip.Prefix, remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package httpx
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ServerTLSConfig returns the listener TLS settings; with a client CA bundle, clients must present a certificate it signed.
+// ServerTLSConfig 는 리스너 TLS 설정을 반환하며, 클라이언트 CA 번들이 있으면 그 CA 가 서명한 인증서를 요구합니다.
+func ServerTLSConfig(clientCAFile string) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if clientCAFile == "" {
+		return config, nil
+	}
+	pem, err := os.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("read client CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("client CA bundle contains no certificates")
+	}
+	config.ClientCAs = pool
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+	return config, nil
+}
+
+// ClientSubject returns the subject of the verified client certificate, preferring its common name, or "".
+// ClientSubject 는 검증된 클라이언트 인증서의 주체를 반환하며, 공통 이름을 우선하고 없으면 "" 를 반환합니다.
+func ClientSubject(state *tls.ConnectionState) string {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	subject := state.VerifiedChains[0][0].Subject
+	if subject.CommonName != "" {
+		return subject.CommonName
+	}
+	return subject.String()
+}
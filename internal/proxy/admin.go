@@ -0,0 +1,238 @@
+package proxy
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// keyRequest is the body accepted when creating a client key.
+// keyRequest 는 클라이언트 키를 만들 때 받는 요청 본문입니다.
+type keyRequest struct {
+	Name      string     `json:"name"`
+	Owner     string     `json:"owner"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	Policy    *Policy    `json:"policy"`
+	RateLimit *RateLimit `json:"rate_limit"`
+	Quota     *Quota     `json:"quota"`
+}
+
+// keyPatch is the body accepted when updating a client key; only fields present in the JSON are changed.
+// keyPatch 는 클라이언트 키를 수정할 때 받는 요청 본문이며, JSON 에 있는 필드만 바꿉니다.
+type keyPatch struct {
+	ExpiresAt json.RawMessage `json:"expires_at"`
+	Policy    json.RawMessage `json:"policy"`
+	RateLimit json.RawMessage `json:"rate_limit"`
+	Quota     json.RawMessage `json:"quota"`
+}
+
+// keyView is a client key as shown by the admin API; the secret is only set right after creation.
+// keyView 는 관리 API 가 보여 주는 클라이언트 키이며, 비밀 값은 생성 직후에만 채워집니다.
+type keyView struct {
+	Key        string     `json:"key,omitempty"`
+	Name       string     `json:"name"`
+	Owner      string     `json:"owner,omitempty"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at,omitzero"`
+	LastUsedAt time.Time  `json:"last_used_at,omitzero"`
+	ExpiresAt  time.Time  `json:"expires_at,omitzero"`
+	RevokedAt  time.Time  `json:"revoked_at,omitzero"`
+	Policy     *Policy    `json:"policy,omitempty"`
+	RateLimit  *RateLimit `json:"rate_limit,omitempty"`
+	Quota      *Quota     `json:"quota,omitempty"`
+}
+
+// newKeyView returns the admin view of key without its hash.
+// newKeyView 는 해시를 뺀 관리용 키 정보를 반환합니다.
+func newKeyView(key ClientKey) keyView {
+	status := "active"
+	switch {
+	case !key.RevokedAt.IsZero():
+		status = "revoked"
+	case !key.Active(time.Now()):
+		status = "expired"
+	}
+	return keyView{
+		Name:       key.Name,
+		Owner:      key.Owner,
+		Status:     status,
+		CreatedAt:  key.CreatedAt,
+		LastUsedAt: key.LastUsedAt,
+		ExpiresAt:  key.ExpiresAt,
+		RevokedAt:  key.RevokedAt,
+		Policy:     key.Policy,
+		RateLimit:  key.RateLimit,
+		Quota:      key.Quota,
+	}
+}
+
+// withAdminAuth returns HTTP middleware that only admits requests bearing the admin token.
+// withAdminAuth 는 관리자 토큰을 가진 요청만 통과시키는 HTTP 미들웨어를 반환합니다.
+func (s *ProxyServer) withAdminAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(secret)), []byte(s.config.AdminToken)) != 1 {
+			writeAdminError(w, http.StatusForbidden, "Invalid admin token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// listKeysHandler lists every client key with its metadata.
+// listKeysHandler 는 모든 클라이언트 키를 메타데이터와 함께 나열합니다.
+func (s *ProxyServer) listKeysHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys := s.config.Keys.List()
+		views := make([]keyView, 0, len(keys))
+		for _, key := range keys {
+			views = append(views, newKeyView(key))
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"keys": views})
+	}
+}
+
+// createKeyHandler mints a client key and returns its secret once.
+// createKeyHandler 는 클라이언트 키를 발급하고 비밀 값을 한 번만 반환합니다.
+func (s *ProxyServer) createKeyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req keyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeAdminError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+		if req.Quota != nil && s.config.Usage == nil {
+			writeAdminError(w, http.StatusBadRequest, "quota requires USAGE_LEDGER")
+			return
+		}
+		var expiresAt time.Time
+		if req.ExpiresAt != nil {
+			expiresAt = *req.ExpiresAt
+		}
+		secret, key, err := s.config.Keys.Create(req.Name, req.Owner, expiresAt, req.Policy, req.RateLimit, req.Quota)
+		if err != nil {
+			s.writeKeyError(w, err)
+			return
+		}
+		log.Printf("admin: created key %q for %q", key.Name, key.Owner)
+		view := newKeyView(key)
+		view.Key = secret
+		writeJSON(w, http.StatusCreated, view)
+	}
+}
+
+// updateKeyHandler changes the expiry, policy, rate limit or quota of a client key; null removes a value.
+// updateKeyHandler 는 클라이언트 키의 만료 시각, 정책, 요청 한도, 할당량을 바꾸며, null 이면 해당 값을 없앱니다.
+func (s *ProxyServer) updateKeyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var patch keyPatch
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+			writeAdminError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+		var expiresAt *time.Time
+		var policy *Policy
+		var rateLimit *RateLimit
+		var quota *Quota
+		if err := decodeOptional(patch.ExpiresAt, &expiresAt); err != nil {
+			writeAdminError(w, http.StatusBadRequest, "invalid expires_at")
+			return
+		}
+		if err := decodeOptional(patch.Policy, &policy); err != nil {
+			writeAdminError(w, http.StatusBadRequest, "invalid policy")
+			return
+		}
+		if err := decodeOptional(patch.RateLimit, &rateLimit); err != nil {
+			writeAdminError(w, http.StatusBadRequest, "invalid rate_limit")
+			return
+		}
+		if err := decodeOptional(patch.Quota, &quota); err != nil {
+			writeAdminError(w, http.StatusBadRequest, "invalid quota")
+			return
+		}
+		if quota != nil && s.config.Usage == nil {
+			writeAdminError(w, http.StatusBadRequest, "quota requires USAGE_LEDGER")
+			return
+		}
+		key, err := s.config.Keys.update(r.PathValue("name"), func(key *ClientKey) {
+			if patch.ExpiresAt != nil {
+				key.ExpiresAt = time.Time{}
+				if expiresAt != nil {
+					key.ExpiresAt = *expiresAt
+				}
+			}
+			if patch.Policy != nil {
+				key.Policy = policy
+			}
+			if patch.RateLimit != nil {
+				key.RateLimit = rateLimit
+			}
+			if patch.Quota != nil {
+				key.Quota = quota
+			}
+		})
+		if err != nil {
+			s.writeKeyError(w, err)
+			return
+		}
+		log.Printf("admin: updated key %q", key.Name)
+		writeJSON(w, http.StatusOK, newKeyView(key))
+	}
+}
+
+// decodeOptional decodes a field that was present in a patch body; absent fields are left untouched.
+// decodeOptional 는 수정 요청 본문에 있던 필드를 디코딩하며, 없던 필드는 건드리지 않습니다.
+func decodeOptional(raw json.RawMessage, v any) error {
+	if raw == nil {
+		return nil
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// revokeKeyHandler revokes a client key.
+// revokeKeyHandler 는 클라이언트 키를 폐기합니다.
+func (s *ProxyServer) revokeKeyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, err := s.config.Keys.Revoke(r.PathValue("name"))
+		if err != nil {
+			s.writeKeyError(w, err)
+			return
+		}
+		log.Printf("admin: revoked key %q", key.Name)
+		writeJSON(w, http.StatusOK, newKeyView(key))
+	}
+}
+
+// writeKeyError maps a KeyStore error to an admin API response.
+// writeKeyError 는 KeyStore 오류를 관리 API 응답으로 변환합니다.
+func (s *ProxyServer) writeKeyError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errKeyNotFound):
+		writeAdminError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, errKeyExists):
+		writeAdminError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, errInvalidKeyName):
+		writeAdminError(w, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("admin: %v", err)
+		writeAdminError(w, http.StatusInternalServerError, "failed to update key file")
+	}
+}
+
+// writeAdminError writes an admin API error as JSON.
+// writeAdminError 는 관리 API 오류를 JSON 으로 작성합니다.
+func writeAdminError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// writeJSON writes v as a JSON response with the given status.
+// writeJSON 는 v 를 지정한 상태 코드의 JSON 응답으로 작성합니다.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -1,39 +1,112 @@
 package proxy
 
 import (
+	"context"
+	"crypto/subtle"
+	"log"
 	"net/http"
 	"strings"
+
+	"github.com/ilcm96/gh-copilot-proxy/internal/httpx"
+)
+
+// contextKey distinguishes values this package stores in request contexts.
+// contextKey 는 이 패키지가 요청 컨텍스트에 저장하는 값을 구분합니다.
+type contextKey int
+
+const (
+	clientKeyContextKey contextKey = iota
+	dialectContextKey
 )
 
-// authorize checks the Authorization header on incoming requests to decide access.
-// authorize 는 수신 요청의 Authorization 헤더를 확인해 접근을 허용할지 결정합니다.
-func (s *ProxyServer) authorize(r *http.Request) bool {
-	header := r.Header.Get("Authorization")
-	if header == "" {
-		header = r.Header.Get("authorization")
+// defaultKey is the identity of callers using the single API_KEY access token.
+// defaultKey 는 단일 API_KEY 접근 토큰을 사용하는 호출자의 식별 정보입니다.
+var defaultKey = &ClientKey{Name: "default"}
+
+// authorize checks the credentials on incoming requests and returns the matching client key.
+// authorize 는 수신 요청의 자격 증명을 확인해 일치하는 클라이언트 키를 반환합니다.
+//
+// With ClientCertBindKeys, the key must also be owned by the subject of the verified client certificate.
+// ClientCertBindKeys 가 켜져 있으면 키의 소유자가 검증된 클라이언트 인증서의 주체와도 같아야 합니다.
+func (s *ProxyServer) authorize(r *http.Request, d dialect) *ClientKey {
+	if s.config.ClientCertIdentity {
+		if subject := httpx.ClientSubject(r.TLS); subject != "" {
+			return &ClientKey{Name: "cert:" + subject}
+		}
+	}
+	key := s.matchKey(presentedKey(r, d))
+	if key == nil || !s.config.ClientCertBindKeys {
+		return key
+	}
+	if subject := httpx.ClientSubject(r.TLS); subject == "" || key.Owner != subject {
+		log.Printf("auth: key %q is not owned by client certificate %q", key.Name, subject)
+		return nil
+	}
+	return key
+}
+
+// matchKey returns the client key whose secret is secret, or nil.
+// matchKey 는 비밀 값이 secret 인 클라이언트 키를 반환하며, 없으면 nil 을 반환합니다.
+func (s *ProxyServer) matchKey(secret string) *ClientKey {
+	if secret == "" {
+		return nil
 	}
-	if header == "" {
-		return false
+	if s.accessToken != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(s.accessToken)) == 1 {
+		return defaultKey
 	}
-	parts := strings.SplitN(header, " ", 2)
-	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
-		return false
+	if s.config.Keys != nil {
+		return s.config.Keys.Match(secret)
 	}
-	return strings.TrimSpace(parts[1]) == s.accessToken
+	return nil
 }
 
-// withAuth returns HTTP middleware that validates the access token.
-// withAuth 는 접근 토큰 검증을 수행하는 HTTP 미들웨어를 반환합니다.
-func (s *ProxyServer) withAuth(next http.Handler) http.Handler {
+// presentedKey returns the key sent as a Bearer token or in the dialect's API key header; Gemini clients may also use the key query parameter.
+// presentedKey 는 Bearer 토큰이나 해당 형식의 API 키 헤더로 전달된 키를 반환하며, Gemini 클라이언트는 key 쿼리 파라미터도 쓸 수 있습니다.
+func presentedKey(r *http.Request, d dialect) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		parts := strings.SplitN(header, " ", 2)
+		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+			return ""
+		}
+		return strings.TrimSpace(parts[1])
+	}
+	if key := strings.TrimSpace(r.Header.Get(d.apiKeyHeader())); key != "" || d != dialectGemini {
+		return key
+	}
+	return strings.TrimSpace(r.URL.Query().Get("key"))
+}
+
+// withAuth returns HTTP middleware that validates the access token and attaches the caller's key and the route's dialect to the context.
+// withAuth 는 접근 토큰을 검증하고 호출자의 키와 라우트의 형식을 컨텍스트에 담는 HTTP 미들웨어를 반환합니다.
+func (s *ProxyServer) withAuth(d dialect, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
 			return
 		}
-		if !s.authorize(r) {
-			http.Error(w, "Invalid access token", http.StatusForbidden)
+		key := s.authorize(r, d)
+		if key == nil {
+			writeDialectError(w, d, http.StatusForbidden, "authentication_error", "Invalid access token")
 			return
 		}
-		next.ServeHTTP(w, r)
+		ctx := context.WithValue(r.Context(), clientKeyContextKey, key)
+		ctx = context.WithValue(ctx, dialectContextKey, d)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// clientKeyFrom returns the client key attached by withAuth, or nil.
+// clientKeyFrom 는 withAuth 가 담아 둔 클라이언트 키를 반환하며, 없으면 nil 을 반환합니다.
+func clientKeyFrom(ctx context.Context) *ClientKey {
+	key, _ := ctx.Value(clientKeyContextKey).(*ClientKey)
+	return key
+}
+
+// keyName returns the caller's key name for logs.
+// keyName 는 로그에 사용할 호출자의 키 이름을 반환합니다.
+func keyName(ctx context.Context) string {
+	if key := clientKeyFrom(ctx); key != nil {
+		return key.Name
+	}
+	return "-"
+}
@@ -0,0 +1,27 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ilcm96/gh-copilot-proxy/internal/adapter"
+	"github.com/ilcm96/gh-copilot-proxy/internal/tokenizer"
+)
+
+// countTokensHandler answers Anthropic count_tokens requests locally with the offline BPE tokenizer.
+// countTokensHandler 는 Anthropic count_tokens 요청에 오프라인 BPE 토크나이저로 직접 응답합니다.
+func (s *ProxyServer) countTokensHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			writeDialectError(w, dialectAnthropic, http.StatusBadRequest, "invalid_request_error", "request body is not valid JSON")
+			return
+		}
+		if model, _ := payload["model"].(string); model == "" {
+			writeDialectError(w, dialectAnthropic, http.StatusBadRequest, "invalid_request_error", "model: field required")
+			return
+		}
+		converted := adapter.ConvertRequestAnthropicToOpenAI(payload)
+		writeJSON(w, http.StatusOK, map[string]int{"input_tokens": tokenizer.CountChat(converted)})
+	}
+}
@@ -0,0 +1,202 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ilcm96/gh-copilot-proxy/internal/adapter"
+	"github.com/ilcm96/gh-copilot-proxy/internal/httpx"
+)
+
+// dialect is the API flavour a route speaks to its clients.
+// dialect 는 라우트가 클라이언트와 주고받는 API 형식입니다.
+type dialect int
+
+const (
+	// dialectOpenAI is the OpenAI-compatible API, which Azure-style clients also use.
+	// dialectOpenAI 는 Azure 형식 클라이언트도 사용하는 OpenAI 호환 API 입니다.
+	dialectOpenAI dialect = iota
+	// dialectAnthropic is the Anthropic Messages API.
+	// dialectAnthropic 는 Anthropic Messages API 입니다.
+	dialectAnthropic
+	// dialectGemini is the Google Gemini API.
+	// dialectGemini 는 Google Gemini API 입니다.
+	dialectGemini
+)
+
+// apiKeyHeader returns the dialect's native API key header besides Authorization.
+// apiKeyHeader 는 Authorization 외에 해당 형식이 기본으로 쓰는 API 키 헤더를 반환합니다.
+func (d dialect) apiKeyHeader() string {
+	switch d {
+	case dialectAnthropic:
+		return "X-Api-Key"
+	case dialectGemini:
+		return "X-Goog-Api-Key"
+	}
+	return "Api-Key"
+}
+
+// dialectFrom returns the dialect of the route serving the request.
+// dialectFrom 는 요청을 처리하는 라우트의 형식을 반환합니다.
+func dialectFrom(ctx context.Context) dialect {
+	d, _ := ctx.Value(dialectContextKey).(dialect)
+	return d
+}
+
+// writeDialectError writes an error body shaped the way clients of the dialect expect.
+// writeDialectError 는 해당 형식의 클라이언트가 기대하는 모양으로 오류 본문을 작성합니다.
+//
+// Gemini errors carry a status name derived from the HTTP status instead of errType.
+// Gemini 오류는 errType 대신 HTTP 상태 코드에서 정한 상태 이름을 담습니다.
+func writeDialectError(w http.ResponseWriter, d dialect, status int, errType, message string) {
+	if d == dialectGemini {
+		writeJSON(w, status, map[string]any{
+			"error": map[string]any{"code": status, "message": message, "status": geminiStatus(status)},
+		})
+		return
+	}
+	if d == dialectAnthropic {
+		writeJSON(w, status, map[string]any{
+			"type":  "error",
+			"error": map[string]any{"type": errType, "message": message},
+		})
+		return
+	}
+	writeJSON(w, status, map[string]any{
+		"error": map[string]any{"message": message, "type": errType, "param": nil, "code": nil},
+	})
+}
+
+// writeStatusError writes an error in the dialect's shape with the error type matching status.
+// writeStatusError 는 상태 코드에 맞는 오류 유형으로 해당 형식의 오류를 작성합니다.
+func writeStatusError(w http.ResponseWriter, d dialect, status int, message string) {
+	writeDialectError(w, d, status, d.errorType(status), message)
+}
+
+// errorType maps an HTTP status to the error type clients of the dialect expect.
+// errorType 는 HTTP 상태 코드를 해당 형식의 클라이언트가 기대하는 오류 유형으로 변환합니다.
+func (d dialect) errorType(status int) string {
+	if d == dialectGemini {
+		return geminiStatus(status)
+	}
+	switch status {
+	case http.StatusUnauthorized:
+		return "authentication_error"
+	case http.StatusForbidden:
+		return "permission_error"
+	case http.StatusNotFound:
+		return "not_found_error"
+	case http.StatusRequestEntityTooLarge:
+		if d == dialectAnthropic {
+			return "request_too_large"
+		}
+	case http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case http.StatusServiceUnavailable, 529:
+		if d == dialectAnthropic {
+			return "overloaded_error"
+		}
+		return "server_error"
+	}
+	switch {
+	case status >= 500 && d == dialectAnthropic:
+		return "api_error"
+	case status >= 500:
+		return "server_error"
+	default:
+		return "invalid_request_error"
+	}
+}
+
+// geminiStatus maps an HTTP status to the Google API status name Gemini clients expect.
+// geminiStatus 는 HTTP 상태 코드를 Gemini 클라이언트가 기대하는 Google API 상태 이름으로 변환합니다.
+func geminiStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "INVALID_ARGUMENT"
+	case http.StatusUnauthorized:
+		return "UNAUTHENTICATED"
+	case http.StatusForbidden:
+		return "PERMISSION_DENIED"
+	case http.StatusNotFound:
+		return "NOT_FOUND"
+	case http.StatusTooManyRequests:
+		return "RESOURCE_EXHAUSTED"
+	case http.StatusServiceUnavailable:
+		return "UNAVAILABLE"
+	case http.StatusGatewayTimeout:
+		return "DEADLINE_EXCEEDED"
+	}
+	if status >= 500 {
+		return "INTERNAL"
+	}
+	return "INVALID_ARGUMENT"
+}
+
+// writeUpstreamError rewrites an upstream error response into the dialect's error shape, keeping its status and message.
+// writeUpstreamError 는 업스트림 오류 응답을 상태 코드와 메시지는 유지한 채 해당 형식의 오류로 다시 작성합니다.
+//
+// OpenAI-shaped errors on OpenAI routes are passed through unchanged.
+// OpenAI 라우트에서 이미 OpenAI 형식인 오류는 그대로 전달합니다.
+func writeUpstreamError(w http.ResponseWriter, d dialect, resp *http.Response) error {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("read upstream error: %w", err)
+	}
+	if d == dialectAnthropic {
+		adapter.CopyAnthropicHeaders(w.Header(), resp.Header, resp.StatusCode, time.Now())
+	} else {
+		httpx.CopyHeaders(w.Header(), resp.Header)
+		w.Header().Del("Content-Length")
+		w.Header().Del("Content-Encoding")
+	}
+	if d == dialectOpenAI && isOpenAIError(body) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(resp.StatusCode)
+		_, err = w.Write(body)
+		return err
+	}
+	writeStatusError(w, d, resp.StatusCode, upstreamErrorMessage(resp.StatusCode, body))
+	return nil
+}
+
+// isOpenAIError reports whether body is already an OpenAI-shaped error object.
+// isOpenAIError 는 본문이 이미 OpenAI 형식의 오류 객체인지 확인합니다.
+func isOpenAIError(body []byte) bool {
+	var payload struct {
+		Error map[string]any `json:"error"`
+	}
+	return json.Unmarshal(body, &payload) == nil && payload.Error["message"] != nil
+}
+
+// upstreamErrorMessage extracts a human-readable message from an upstream error body of any shape.
+// upstreamErrorMessage 는 어떤 형태의 업스트림 오류 본문에서든 읽을 수 있는 메시지를 꺼냅니다.
+func upstreamErrorMessage(status int, body []byte) string {
+	var payload struct {
+		Error   json.RawMessage `json:"error"`
+		Message string          `json:"message"`
+	}
+	if json.Unmarshal(body, &payload) == nil {
+		var nested struct {
+			Message string `json:"message"`
+		}
+		var text string
+		switch {
+		case json.Unmarshal(payload.Error, &nested) == nil && nested.Message != "":
+			return nested.Message
+		case json.Unmarshal(payload.Error, &text) == nil && text != "":
+			return text
+		case payload.Message != "":
+			return payload.Message
+		}
+	}
+	if text := strings.TrimSpace(string(body)); text != "" {
+		return text
+	}
+	return fmt.Sprintf("upstream returned %d %s", status, http.StatusText(status))
+}
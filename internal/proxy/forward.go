@@ -6,12 +6,48 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/ilcm96/gh-copilot-proxy/internal/auth"
 	"github.com/ilcm96/gh-copilot-proxy/internal/httpx"
+	"github.com/ilcm96/gh-copilot-proxy/internal/usage"
 )
 
+// clientOnlyHeaders are client credentials and Anthropic protocol headers that must never reach Copilot.
+// clientOnlyHeaders 는 Copilot 으로 절대 전달하면 안 되는 클라이언트 자격 증명과 Anthropic 프로토콜 헤더입니다.
+var clientOnlyHeaders = map[string]struct{}{
+	"authorization":     {},
+	"x-api-key":         {},
+	"api-key":           {},
+	"anthropic-version": {},
+	"anthropic-beta":    {},
+	"x-goog-api-key":    {},
+}
+
+// errCopilotUnavailable reports that no account currently holds a usable Copilot token.
+// errCopilotUnavailable 는 사용 가능한 Copilot 토큰을 가진 계정이 없음을 나타냅니다.
+var errCopilotUnavailable = errors.New("copilot token unavailable")
+
+// errInvalidRequest reports that the client request could not be read or translated.
+// errInvalidRequest 는 클라이언트 요청을 읽거나 변환할 수 없음을 나타냅니다.
+var errInvalidRequest = errors.New("invalid request")
+
+// errUserToken reports that a client-supplied GitHub OAuth token could not be used.
+// errUserToken 는 클라이언트가 제공한 GitHub OAuth 토큰을 사용할 수 없음을 나타냅니다.
+var errUserToken = errors.New("user GitHub token rejected")
+
+// errChatDisabled reports that every usable account has Copilot chat disabled for its seat.
+// errChatDisabled 는 사용 가능한 모든 계정의 좌석에서 Copilot chat 이 꺼져 있음을 나타냅니다.
+var errChatDisabled = errors.New("copilot chat is disabled for every seat")
+
+// errResponseStarted reports a failure after the response status was sent, when no error body can follow.
+// errResponseStarted 는 응답 상태 코드를 보낸 뒤의 실패로, 오류 본문을 더 보낼 수 없음을 나타냅니다.
+var errResponseStarted = errors.New("response already started")
+
 // ProxyOptions defines request/response transformation hooks during proxying.
 // ProxyOptions 는 프록시 과정에서 요청/응답 변환 훅을 정의합니다.
 type ProxyOptions struct {
@@ -19,24 +55,193 @@ type ProxyOptions struct {
 	TransformResponse func(http.ResponseWriter, *http.Response) error
 }
 
-// forward sends the client request to the Copilot API and writes the response back.
-// forward 는 클라이언트 요청을 Copilot API 에 전달하고 응답을 작성합니다.
-func (s *ProxyServer) forward(w http.ResponseWriter, r *http.Request, target string, opts *ProxyOptions) error {
+// forward sends the client request to the given Copilot API path and writes the response back.
+// forward 는 클라이언트 요청을 지정한 Copilot API 경로로 전달하고 응답을 작성합니다.
+//
+// Failures after the response status was sent wrap errResponseStarted.
+// 응답 상태 코드를 보낸 뒤의 실패는 errResponseStarted 를 감쌉니다.
+func (s *ProxyServer) forward(w http.ResponseWriter, r *http.Request, path string, opts *ProxyOptions) (err error) {
+	start := time.Now()
+	sw := &startedWriter{ResponseWriter: w}
+	w = sw
+	defer func() {
+		if err != nil && sw.started {
+			err = fmt.Errorf("%w: %w", errResponseStarted, err)
+		}
+	}()
+
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		return fmt.Errorf("read request body: %w", err)
+		return fmt.Errorf("%w: read request body: %w", errInvalidRequest, err)
 	}
 
 	if opts != nil && opts.TransformRequest != nil {
 		body, err = opts.TransformRequest(body)
 		if err != nil {
-			return fmt.Errorf("transform request: %w", err)
+			return fmt.Errorf("%w: transform request: %w", errInvalidRequest, err)
 		}
 	}
 
+	var resp *http.Response
+	if oauth := s.userToken(r); oauth != "" {
+		resp, err = s.sendAsUser(r, oauth, path, body)
+	} else {
+		resp, err = s.sendPooled(r, path, body)
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if s.config.Usage != nil {
+		meter := usage.NewMeter(resp.Body, isEventStream(resp.Header))
+		resp.Body = meter
+		defer s.recordUsage(r, body, resp.StatusCode, meter, start)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return writeUpstreamError(w, dialectFrom(r.Context()), resp)
+	}
+	if opts != nil && opts.TransformResponse != nil {
+		return opts.TransformResponse(w, resp)
+	}
+
+	httpx.CopyHeaders(w.Header(), resp.Header)
+	w.Header().Del("Content-Length")
+	w.WriteHeader(resp.StatusCode)
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// startedWriter records whether the response status has been sent, so later failures do not write a second one.
+// startedWriter 는 응답 상태 코드를 보냈는지 기록하여, 이후 실패 때 상태 코드를 두 번 쓰지 않게 합니다.
+type startedWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (w *startedWriter) WriteHeader(status int) {
+	w.started = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *startedWriter) Write(b []byte) (int, error) {
+	w.started = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *startedWriter) Flush() {
+	w.started = true
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *startedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// userToken returns the client's own GitHub OAuth token when passthrough is enabled.
+// userToken 는 전달 모드가 켜져 있으면 클라이언트 자신의 GitHub OAuth 토큰을 반환합니다.
+func (s *ProxyServer) userToken(r *http.Request) string {
+	if s.config.UserTokenHeader == "" {
+		return ""
+	}
+	return strings.TrimSpace(r.Header.Get(s.config.UserTokenHeader))
+}
+
+// sendAsUser sends the request on the seat of the client-supplied OAuth token.
+// sendAsUser 는 클라이언트가 제공한 OAuth 토큰의 좌석으로 요청을 보냅니다.
+func (s *ProxyServer) sendAsUser(r *http.Request, oauth, path string, body []byte) (*http.Response, error) {
+	account, err := s.auth.ForUser(oauth)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errUserToken, err)
+	}
+	if path == "/chat/completions" && !account.Token().ChatEnabled {
+		return nil, fmt.Errorf("%w: chat is disabled for this Copilot seat", errUserToken)
+	}
+	return s.roundTrip(r, account, path, body)
+}
+
+// sendPooled sends the request on a pooled account, failing over to another account on 401/403/429.
+// sendPooled 는 풀의 계정으로 요청을 보내며, 401/403/429 를 받으면 다른 계정으로 전환합니다.
+func (s *ProxyServer) sendPooled(r *http.Request, path string, body []byte) (*http.Response, error) {
+	tried := make(map[*auth.CopilotAuth]bool)
+	chatDisabled := false
+	var resp *http.Response
+	for {
+		account := s.auth.Acquire(tried)
+		if account == nil {
+			if chatDisabled {
+				return nil, errChatDisabled
+			}
+			return nil, errCopilotUnavailable
+		}
+		tried[account] = true
+		if path == "/chat/completions" && !account.Token().ChatEnabled {
+			chatDisabled = true
+			continue
+		}
+
+		var err error
+		resp, err = s.roundTrip(r, account, path, body)
+		if err != nil {
+			return nil, err
+		}
+		if !isFailoverStatus(resp.StatusCode) {
+			break
+		}
+		cooldown := failoverCooldown(resp)
+		account.MarkThrottled(cooldown)
+		if len(tried) >= s.auth.Len() {
+			break
+		}
+		log.Printf("%s: upstream returned %d, failing over (cooldown %s)", account.Name(), resp.StatusCode, cooldown)
+		resp.Body.Close()
+	}
+	return resp, nil
+}
+
+// roundTrip sends the request with the account's token, refreshing and replaying it once on 401.
+// roundTrip 는 계정 토큰으로 요청을 보내며, 401 을 받으면 토큰을 갱신해 한 번 재전송합니다.
+func (s *ProxyServer) roundTrip(r *http.Request, account *auth.CopilotAuth, path string, body []byte) (*http.Response, error) {
+	bearer := account.BearerToken()
+	resp, err := s.send(r, account.APIBaseURL()+path, body, bearer)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	if err := account.RefreshStale(bearer); err != nil {
+		log.Printf("%s: refresh after 401 failed: %v", account.Name(), err)
+		return resp, nil
+	}
+	resp.Body.Close()
+	log.Printf("%s: replaying request after token refresh", account.Name())
+	return s.send(r, account.APIBaseURL()+path, body, account.BearerToken())
+}
+
+// send performs a single upstream request.
+// send 는 업스트림 요청을 한 번 수행합니다.
+func (s *ProxyServer) send(r *http.Request, target string, body []byte, bearer string) (*http.Response, error) {
+	req, err := newUpstreamRequest(r, target, body, bearer)
+	if err != nil {
+		return nil, err
+	}
+	if s.config.UserTokenHeader != "" {
+		req.Header.Del(s.config.UserTokenHeader)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("proxy request: %w", err)
+	}
+	return resp, nil
+}
+
+// newUpstreamRequest builds the Copilot API request from the client request and a bearer token.
+// newUpstreamRequest 는 클라이언트 요청과 Bearer 토큰으로 Copilot API 요청을 만듭니다.
+func newUpstreamRequest(r *http.Request, target string, body []byte, bearer string) (*http.Request, error) {
 	req, err := http.NewRequestWithContext(r.Context(), r.Method, target, bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("build upstream request: %w", err)
+		return nil, fmt.Errorf("build upstream request: %w", err)
 	}
 
 	for key, values := range r.Header {
@@ -44,7 +249,10 @@ func (s *ProxyServer) forward(w http.ResponseWriter, r *http.Request, target str
 		if _, skip := httpx.HopByHopHeaders[lower]; skip {
 			continue
 		}
-		if lower == "host" || lower == "authorization" || lower == "content-length" {
+		if _, skip := clientOnlyHeaders[lower]; skip {
+			continue
+		}
+		if lower == "host" || lower == "content-length" {
 			continue
 		}
 		for _, value := range values {
@@ -52,32 +260,36 @@ func (s *ProxyServer) forward(w http.ResponseWriter, r *http.Request, target str
 		}
 	}
 
-	bearer := s.auth.BearerToken()
-	if bearer == "" {
-		return errors.New("copilot token unavailable")
-	}
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", bearer))
 	req.Header.Set("Copilot-Integration-Id", "vscode-chat")
 	req.Header.Set("Editor-Version", "Neovim/0.9.0")
 	if hasVisionContent(body) {
 		req.Header.Set("Copilot-Vision-Request", "true")
 	}
+	return req, nil
+}
 
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("proxy request: %w", err)
-	}
-	defer resp.Body.Close()
+// isFailoverStatus reports whether an upstream status should move the request to another account.
+// isFailoverStatus 는 업스트림 상태 코드가 다른 계정으로 넘겨야 하는 경우인지 확인합니다.
+func isFailoverStatus(status int) bool {
+	return status == http.StatusUnauthorized || status == http.StatusForbidden || status == http.StatusTooManyRequests
+}
 
-	if opts != nil && opts.TransformResponse != nil {
-		return opts.TransformResponse(w, resp)
+// failoverCooldown decides how long an account stays out of rotation after a failover response.
+// failoverCooldown 는 페일오버 응답 후 계정을 순환에서 제외할 시간을 결정합니다.
+func failoverCooldown(resp *http.Response) time.Duration {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		if secs, err := strconv.Atoi(strings.TrimSpace(resp.Header.Get("Retry-After"))); err == nil && secs > 0 {
+			return time.Duration(secs) * time.Second
+		}
 	}
+	return time.Minute
+}
 
-	httpx.CopyHeaders(w.Header(), resp.Header)
-	w.Header().Del("Content-Length")
-	w.WriteHeader(resp.StatusCode)
-	_, err = io.Copy(w, resp.Body)
-	return err
+// isEventStream reports whether a response is a server-sent event stream.
+// isEventStream 는 응답이 SSE 스트림인지 확인합니다.
+func isEventStream(h http.Header) bool {
+	return strings.Contains(strings.ToLower(h.Get("Content-Type")), "text/event-stream")
 }
 
 // hasVisionContent checks for image content in an OpenAI-style request body.
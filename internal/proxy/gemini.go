@@ -0,0 +1,55 @@
+package proxy
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/ilcm96/gh-copilot-proxy/internal/adapter"
+)
+
+// geminiTarget splits the {target} path value of a Gemini route, such as "gemini-2.5-pro:generateContent", into model and method.
+// geminiTarget 는 "gemini-2.5-pro:generateContent" 같은 Gemini 라우트의 {target} 경로 값을 모델과 메서드로 나눕니다.
+func geminiTarget(r *http.Request) (string, string) {
+	target := r.PathValue("target")
+	i := strings.LastIndex(target, ":")
+	if i < 0 {
+		return target, ""
+	}
+	return target[:i], target[i+1:]
+}
+
+// geminiHandler creates a handler for the Gemini generateContent and streamGenerateContent methods, served on top of chat/completions.
+// geminiHandler 는 chat/completions 위에서 동작하는 Gemini generateContent, streamGenerateContent 메서드 핸들러를 생성합니다.
+func (s *ProxyServer) geminiHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		model, method := geminiTarget(r)
+		if method != "generateContent" && method != "streamGenerateContent" {
+			writeStatusError(w, dialectGemini, http.StatusNotFound, "method "+method+" is not supported for models/"+model)
+			return
+		}
+		stream := method == "streamGenerateContent"
+		sse := r.URL.Query().Get("alt") == "sse"
+		opts := &ProxyOptions{
+			TransformRequest: func(body []byte) ([]byte, error) {
+				var request map[string]any
+				if err := json.Unmarshal(body, &request); err != nil {
+					return nil, err
+				}
+				converted, err := adapter.ConvertRequestGeminiToOpenAI(model, request, stream)
+				if err != nil {
+					return nil, err
+				}
+				return json.Marshal(converted)
+			},
+			TransformResponse: func(w http.ResponseWriter, resp *http.Response) error {
+				return adapter.TransformOpenAIResponseToGemini(w, resp, model, stream, sse)
+			},
+		}
+		if err := s.forward(w, r, "/chat/completions", opts); err != nil {
+			log.Printf("gemini proxy error [%s]: %v", keyName(r.Context()), err)
+			s.writeForwardError(w, r, err)
+		}
+	}
+}
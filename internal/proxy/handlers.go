@@ -2,19 +2,22 @@ package proxy
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 
 	"github.com/ilcm96/gh-copilot-proxy/internal/adapter"
+	"github.com/ilcm96/gh-copilot-proxy/internal/auth"
 )
 
-// proxyHandler creates an HTTP handler that performs a simple proxy.
-// proxyHandler 는 단순 프록시를 수행하는 HTTP 핸들러를 생성합니다.
-func (s *ProxyServer) proxyHandler(target string) http.HandlerFunc {
+// proxyHandler creates an HTTP handler that performs a simple proxy to the given Copilot API path.
+// proxyHandler 는 지정한 Copilot API 경로로 단순 프록시를 수행하는 HTTP 핸들러를 생성합니다.
+func (s *ProxyServer) proxyHandler(path string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if err := s.forward(w, r, target, nil); err != nil {
-			log.Printf("proxy error: %v", err)
-			http.Error(w, "proxy error", http.StatusBadGateway)
+		if err := s.forward(w, r, path, nil); err != nil {
+			log.Printf("proxy error [%s]: %v", keyName(r.Context()), err)
+			s.writeForwardError(w, r, err)
 		}
 	}
 }
@@ -22,7 +25,6 @@ func (s *ProxyServer) proxyHandler(target string) http.HandlerFunc {
 // messagesHandler creates a handler for the Anthropic-compatible messages endpoint.
 // messagesHandler 는 Anthropic 호환 메시지 엔드포인트를 처리하는 핸들러를 생성합니다.
 func (s *ProxyServer) messagesHandler() http.HandlerFunc {
-	target := "https://api.githubcopilot.com/chat/completions"
 	opts := &ProxyOptions{
 		TransformRequest: func(body []byte) ([]byte, error) {
 			if len(body) == 0 {
@@ -40,9 +42,116 @@ func (s *ProxyServer) messagesHandler() http.HandlerFunc {
 		},
 	}
 	return func(w http.ResponseWriter, r *http.Request) {
-		if err := s.forward(w, r, target, opts); err != nil {
-			log.Printf("messages proxy error: %v", err)
-			http.Error(w, "proxy error", http.StatusBadGateway)
+		if err := s.forward(w, r, "/chat/completions", opts); err != nil {
+			log.Printf("messages proxy error [%s]: %v", keyName(r.Context()), err)
+			s.writeForwardError(w, r, err)
 		}
 	}
 }
+
+// completionsHandler creates a handler for the OpenAI legacy completions endpoint.
+// completionsHandler 는 OpenAI 레거시 completions 엔드포인트를 처리하는 핸들러를 생성합니다.
+func (s *ProxyServer) completionsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]any
+		opts := &ProxyOptions{
+			TransformRequest: func(body []byte) ([]byte, error) {
+				if err := json.Unmarshal(body, &request); err != nil {
+					return nil, err
+				}
+				converted, err := adapter.ConvertRequestCompletionsToOpenAI(request)
+				if err != nil {
+					return nil, err
+				}
+				return json.Marshal(converted)
+			},
+			TransformResponse: func(w http.ResponseWriter, resp *http.Response) error {
+				return adapter.TransformOpenAIResponseToCompletions(w, resp, request)
+			},
+		}
+		if err := s.forward(w, r, "/chat/completions", opts); err != nil {
+			log.Printf("completions proxy error [%s]: %v", keyName(r.Context()), err)
+			s.writeForwardError(w, r, err)
+		}
+	}
+}
+
+// completeHandler creates a handler for the Anthropic legacy complete endpoint.
+// completeHandler 는 Anthropic 레거시 complete 엔드포인트를 처리하는 핸들러를 생성합니다.
+func (s *ProxyServer) completeHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]any
+		opts := &ProxyOptions{
+			TransformRequest: func(body []byte) ([]byte, error) {
+				if err := json.Unmarshal(body, &request); err != nil {
+					return nil, err
+				}
+				return json.Marshal(adapter.ConvertRequestCompleteToOpenAI(request))
+			},
+			TransformResponse: func(w http.ResponseWriter, resp *http.Response) error {
+				return adapter.TransformOpenAIResponseToComplete(w, resp, request)
+			},
+		}
+		if err := s.forward(w, r, "/chat/completions", opts); err != nil {
+			log.Printf("complete proxy error [%s]: %v", keyName(r.Context()), err)
+			s.writeForwardError(w, r, err)
+		}
+	}
+}
+
+// writeForwardError writes an error in the caller's dialect with the status matching a forward failure.
+// writeForwardError 는 포워딩 실패에 맞는 상태 코드로 호출자 형식의 오류를 작성합니다.
+func (s *ProxyServer) writeForwardError(w http.ResponseWriter, r *http.Request, err error) {
+	d := dialectFrom(r.Context())
+	switch {
+	case errors.Is(err, errResponseStarted):
+		// The status and part of the body are already out; the caller has logged the error and the client sees a cut-off response.
+		// 상태 코드와 본문 일부가 이미 나갔으므로, 호출자가 오류를 기록하고 클라이언트는 끊긴 응답을 받습니다.
+	case errors.Is(err, errCopilotUnavailable):
+		w.Header().Set("Retry-After", "5")
+		writeStatusError(w, d, http.StatusServiceUnavailable, fmt.Sprintf("copilot auth is %s", s.auth.Health()))
+	case errors.Is(err, errChatDisabled):
+		writeStatusError(w, d, http.StatusForbidden, err.Error())
+	case errors.Is(err, errUserToken):
+		writeStatusError(w, d, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, errInvalidRequest):
+		writeStatusError(w, d, http.StatusBadRequest, err.Error())
+	default:
+		writeStatusError(w, d, http.StatusBadGateway, "proxy error")
+	}
+}
+
+// healthHandler reports the aggregate auth state of the pool; it answers 503 while no account is usable.
+// healthHandler 는 풀 전체의 인증 상태를 보고하며, 사용 가능한 계정이 없으면 503 을 반환합니다.
+//
+// The route is unauthenticated, so per-account detail is only served by adminHealthHandler.
+// 이 라우트는 인증이 없으므로 계정별 정보는 adminHealthHandler 에서만 제공합니다.
+func (s *ProxyServer) healthHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, s.auth.Health(), nil)
+	}
+}
+
+// adminHealthHandler reports the auth state of the pool together with the state and token expiry of every account.
+// adminHealthHandler 는 풀의 인증 상태와 함께 각 계정의 상태와 토큰 만료 시각을 보고합니다.
+func (s *ProxyServer) adminHealthHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, s.auth.Health(), s.auth.Accounts())
+	}
+}
+
+// writeHealth writes the health document, omitting the accounts when they are nil.
+// writeHealth 는 상태 문서를 작성하며, accounts 가 nil 이면 생략합니다.
+func writeHealth(w http.ResponseWriter, health auth.Health, accounts []auth.AccountHealth) {
+	status := http.StatusOK
+	if !health.Usable() {
+		status = http.StatusServiceUnavailable
+	}
+	body := map[string]any{"status": health.String()}
+	if accounts != nil {
+		body["accounts"] = accounts
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
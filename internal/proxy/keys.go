@@ -0,0 +1,361 @@
+package proxy
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ilcm96/gh-copilot-proxy/internal/fileutil"
+	"github.com/ilcm96/gh-copilot-proxy/internal/fswatch"
+)
+
+// usageFlushInterval is how often last-used times are written back to the key file.
+// usageFlushInterval 는 마지막 사용 시각을 키 파일에 다시 기록하는 주기입니다.
+const usageFlushInterval = time.Minute
+
+var (
+	errKeyNotFound = errors.New("key not found")
+	errKeyExists   = errors.New("key already exists")
+
+	errInvalidKeyName = errors.New("invalid key name")
+)
+
+// ClientKey is a named client API key, stored only as a SHA-256 hash.
+// ClientKey 는 SHA-256 해시로만 저장되는 이름 있는 클라이언트 API 키입니다.
+//
+// Keys are shared with in-flight requests, so they are replaced rather than modified in place.
+// 키는 처리 중인 요청과 공유되므로 제자리에서 수정하지 않고 교체합니다.
+type ClientKey struct {
+	Name       string     `json:"name"`
+	Hash       string     `json:"hash"`
+	Owner      string     `json:"owner,omitempty"`
+	CreatedAt  time.Time  `json:"created_at,omitzero"`
+	LastUsedAt time.Time  `json:"last_used_at,omitzero"`
+	ExpiresAt  time.Time  `json:"expires_at,omitzero"`
+	RevokedAt  time.Time  `json:"revoked_at,omitzero"`
+	Policy     *Policy    `json:"policy,omitempty"`
+	RateLimit  *RateLimit `json:"rate_limit,omitempty"`
+	Quota      *Quota     `json:"quota,omitempty"`
+}
+
+// Active reports whether the key is neither revoked nor expired at now.
+// Active 는 now 시점에 키가 폐기되거나 만료되지 않았는지 확인합니다.
+func (c *ClientKey) Active(now time.Time) bool {
+	if !c.RevokedAt.IsZero() {
+		return false
+	}
+	return c.ExpiresAt.IsZero() || now.Before(c.ExpiresAt)
+}
+
+// keyFile is the on-disk layout of the key file.
+// keyFile 는 키 파일의 디스크 저장 형식입니다.
+type keyFile struct {
+	Keys []*ClientKey `json:"keys"`
+}
+
+// KeyStore holds the client keys loaded from a key file.
+// KeyStore 는 키 파일에서 읽은 클라이언트 키들을 보관합니다.
+type KeyStore struct {
+	path string
+
+	mu   sync.RWMutex
+	keys []*ClientKey
+
+	usedMu sync.Mutex
+	used   map[string]time.Time
+}
+
+// NewKeyStore loads the client keys from path; a missing file starts an empty store.
+// NewKeyStore 는 path 에서 클라이언트 키들을 읽어 오며, 파일이 없으면 빈 저장소로 시작합니다.
+func NewKeyStore(path string) (*KeyStore, error) {
+	store := &KeyStore{path: path, used: make(map[string]time.Time)}
+	if err := store.load(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// GenerateKey returns a new random client secret.
+// GenerateKey 는 새 임의 클라이언트 비밀 값을 반환합니다.
+func GenerateKey() string {
+	buf := make([]byte, 32)
+	_, _ = rand.Read(buf)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// Watch reloads the key file whenever it changes and periodically records last-used times until ctx is cancelled.
+// Watch 는 ctx 가 취소될 때까지 키 파일이 바뀔 때마다 다시 읽고 마지막 사용 시각을 주기적으로 기록합니다.
+func (k *KeyStore) Watch(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(usageFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := k.Flush(); err != nil {
+					log.Printf("key file write failed: %v", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	fswatch.Watch(ctx, 2*time.Second, []string{k.path}, func() {
+		if err := k.load(); err != nil {
+			log.Printf("key file reload failed, keeping previous keys: %v", err)
+		}
+	})
+}
+
+// load reads and validates the key file, replacing the keys in memory.
+// load 는 키 파일을 읽고 검증한 뒤 메모리의 키를 교체합니다.
+func (k *KeyStore) load() error {
+	var file keyFile
+	data, err := os.ReadFile(k.path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &file); err != nil {
+			return fmt.Errorf("parse key file %s: %w", k.path, err)
+		}
+	case errors.Is(err, os.ErrNotExist):
+	default:
+		return fmt.Errorf("read key file: %w", err)
+	}
+	for i, key := range file.Keys {
+		if key == nil || key.Name == "" {
+			return fmt.Errorf("key file %s: entry %d has no name", k.path, i)
+		}
+		if _, err := decodeKeyHash(key.Hash); err != nil {
+			return fmt.Errorf("key file %s: key %q: %w", k.path, key.Name, err)
+		}
+	}
+
+	k.mu.Lock()
+	k.keys = file.Keys
+	k.mu.Unlock()
+	log.Printf("loaded %d client key(s) from %s", len(file.Keys), k.path)
+	return nil
+}
+
+// save writes the keys to the key file; the caller holds mu.
+// save 는 키들을 키 파일에 기록하며, 호출자가 mu 를 잡고 있어야 합니다.
+func (k *KeyStore) save() error {
+	data, err := json.MarshalIndent(keyFile{Keys: k.keys}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := fileutil.WriteAtomic(k.path, data); err != nil {
+		return fmt.Errorf("write key file %s: %w", k.path, err)
+	}
+	return nil
+}
+
+// Match returns the active key whose hash matches the presented secret, or nil.
+// Match 는 제시된 비밀 값과 해시가 일치하는 활성 키를 반환하며, 없으면 nil 을 반환합니다.
+//
+// Every key is compared in constant time so the position of a match is not observable.
+// 일치 위치가 드러나지 않도록 모든 키를 상수 시간으로 비교합니다.
+func (k *KeyStore) Match(secret string) *ClientKey {
+	sum := sha256.Sum256([]byte(secret))
+	now := time.Now()
+	k.mu.RLock()
+	var matched *ClientKey
+	for _, key := range k.keys {
+		want, err := decodeKeyHash(key.Hash)
+		if err != nil {
+			continue
+		}
+		if subtle.ConstantTimeCompare(sum[:], want) == 1 && matched == nil {
+			matched = key
+		}
+	}
+	k.mu.RUnlock()
+	if matched == nil || !matched.Active(now) {
+		return nil
+	}
+
+	k.usedMu.Lock()
+	k.used[matched.Name] = now
+	k.usedMu.Unlock()
+	return matched
+}
+
+// List returns copies of all keys, including revoked and expired ones, with up-to-date last-used times.
+// List 는 폐기되거나 만료된 키를 포함한 모든 키의 사본을 최신 마지막 사용 시각과 함께 반환합니다.
+func (k *KeyStore) List() []ClientKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	keys := make([]ClientKey, 0, len(k.keys))
+	for _, key := range k.keys {
+		keys = append(keys, k.withLastUsed(*key))
+	}
+	return keys
+}
+
+// withLastUsed returns key with the last-used time recorded since the previous flush.
+// withLastUsed 는 이전 기록 이후 쌓인 마지막 사용 시각을 반영한 key 를 반환합니다.
+func (k *KeyStore) withLastUsed(key ClientKey) ClientKey {
+	k.usedMu.Lock()
+	defer k.usedMu.Unlock()
+	if used := k.used[key.Name]; used.After(key.LastUsedAt) {
+		key.LastUsedAt = used.UTC().Truncate(time.Second)
+	}
+	return key
+}
+
+// Create mints a new key and persists its hash, returning the secret, which is not stored anywhere.
+// Create 는 새 키를 발급하고 해시를 저장하며, 어디에도 저장되지 않는 비밀 값을 반환합니다.
+func (k *KeyStore) Create(name, owner string, expiresAt time.Time, policy *Policy, rateLimit *RateLimit, quota *Quota) (string, ClientKey, error) {
+	if err := validateKeyName(name); err != nil {
+		return "", ClientKey{}, err
+	}
+	secret := GenerateKey()
+	sum := sha256.Sum256([]byte(secret))
+	key := &ClientKey{
+		Name:      name,
+		Hash:      "sha256:" + hex.EncodeToString(sum[:]),
+		Owner:     owner,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+		ExpiresAt: expiresAt,
+		Policy:    policy,
+		RateLimit: rateLimit,
+		Quota:     quota,
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.indexOf(name) >= 0 {
+		return "", ClientKey{}, fmt.Errorf("%w: %s", errKeyExists, name)
+	}
+	k.keys = append(k.keys, key)
+	if err := k.save(); err != nil {
+		k.keys = k.keys[:len(k.keys)-1]
+		return "", ClientKey{}, err
+	}
+	return secret, *key, nil
+}
+
+// Revoke marks the named key as revoked; the entry is kept for auditing.
+// Revoke 는 지정한 키를 폐기 상태로 표시하며, 감사를 위해 항목은 남겨 둡니다.
+func (k *KeyStore) Revoke(name string) (ClientKey, error) {
+	return k.update(name, func(key *ClientKey) {
+		if key.RevokedAt.IsZero() {
+			key.RevokedAt = time.Now().UTC().Truncate(time.Second)
+		}
+	})
+}
+
+// update replaces the named key with a modified copy and persists the result.
+// update 는 지정한 키를 수정한 사본으로 교체하고 결과를 저장합니다.
+func (k *KeyStore) update(name string, modify func(*ClientKey)) (ClientKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	i := k.indexOf(name)
+	if i < 0 {
+		return ClientKey{}, fmt.Errorf("%w: %s", errKeyNotFound, name)
+	}
+	previous := k.keys[i]
+	updated := *previous
+	modify(&updated)
+	k.keys[i] = &updated
+	if err := k.save(); err != nil {
+		k.keys[i] = previous
+		return ClientKey{}, err
+	}
+	return k.withLastUsed(updated), nil
+}
+
+// Flush writes last-used times recorded since the previous flush to the key file.
+// When the save fails, the times are kept for the next flush.
+// Flush 는 이전 기록 이후 쌓인 마지막 사용 시각을 키 파일에 기록합니다.
+// 저장에 실패하면 그 시각들은 다음 기록 때까지 보관합니다.
+func (k *KeyStore) Flush() error {
+	k.usedMu.Lock()
+	used := k.used
+	k.used = make(map[string]time.Time)
+	k.usedMu.Unlock()
+	if len(used) == 0 {
+		return nil
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	previous := k.keys
+	keys := make([]*ClientKey, len(previous))
+	for i, key := range previous {
+		keys[i] = key
+		if t, ok := used[key.Name]; ok && t.After(key.LastUsedAt) {
+			c := *key
+			c.LastUsedAt = t.UTC().Truncate(time.Second)
+			keys[i] = &c
+		}
+	}
+	k.keys = keys
+	if err := k.save(); err != nil {
+		k.keys = previous
+		k.restoreUsed(used)
+		return err
+	}
+	return nil
+}
+
+// restoreUsed puts back last-used times that could not be saved, keeping any newer time recorded since.
+// restoreUsed 는 저장하지 못한 마지막 사용 시각을 되돌려 놓으며, 그 사이 기록된 더 최근 시각은 유지합니다.
+func (k *KeyStore) restoreUsed(used map[string]time.Time) {
+	k.usedMu.Lock()
+	defer k.usedMu.Unlock()
+	for name, t := range used {
+		if current, ok := k.used[name]; !ok || t.After(current) {
+			k.used[name] = t
+		}
+	}
+}
+
+// indexOf returns the position of the named key, or -1; the caller holds mu.
+// indexOf 는 지정한 키의 위치를 반환하며 없으면 -1 을 반환하고, 호출자가 mu 를 잡고 있어야 합니다.
+func (k *KeyStore) indexOf(name string) int {
+	for i, key := range k.keys {
+		if key.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// validateKeyName checks that a key name is usable in logs and URL paths.
+// validateKeyName 는 키 이름이 로그와 URL 경로에 쓸 수 있는 형태인지 확인합니다.
+func validateKeyName(name string) error {
+	if name == "" || len(name) > 64 {
+		return fmt.Errorf("%w: must be 1 to 64 characters", errInvalidKeyName)
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == '@':
+		default:
+			return fmt.Errorf("%w: character %q is not allowed", errInvalidKeyName, r)
+		}
+	}
+	return nil
+}
+
+// decodeKeyHash parses a key hash written as hex, optionally prefixed with "sha256:".
+// decodeKeyHash 는 "sha256:" 접두사를 붙일 수 있는 16진수 키 해시를 파싱합니다.
+func decodeKeyHash(hash string) ([]byte, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(hash), "sha256:"))
+	if err != nil || len(raw) != sha256.Size {
+		return nil, fmt.Errorf("hash must be a hex SHA-256 digest")
+	}
+	return raw, nil
+}
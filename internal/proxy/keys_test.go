@@ -0,0 +1,50 @@
+package proxy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestKeyStoreFlushKeepsUsedOnSaveError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "keys.json")
+	store, err := NewKeyStore(path)
+	if err != nil {
+		t.Fatalf("NewKeyStore() error = %v", err)
+	}
+	secret, _, err := store.Create("ci", "alice", time.Time{}, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if store.Match(secret) == nil {
+		t.Fatal("Match() did not find the new key")
+	}
+
+	// A regular file in place of the directory makes the save fail.
+	blocker := filepath.Join(dir, "blocker")
+	if err := os.WriteFile(blocker, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store.path = filepath.Join(blocker, "keys.json")
+	if err := store.Flush(); err == nil {
+		t.Fatal("Flush() into an unwritable path returned no error")
+	}
+	if _, ok := store.used["ci"]; !ok {
+		t.Fatal("Flush() dropped the last-used time after a failed save")
+	}
+
+	store.path = path
+	if err := store.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	reloaded, err := NewKeyStore(path)
+	if err != nil {
+		t.Fatalf("NewKeyStore() error = %v", err)
+	}
+	keys := reloaded.List()
+	if len(keys) != 1 || keys[0].LastUsedAt.IsZero() {
+		t.Errorf("reloaded keys = %+v, want ci with a last-used time", keys)
+	}
+}
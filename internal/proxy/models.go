@@ -0,0 +1,286 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultModelsTTL is how long the Copilot model catalog is cached when no TTL is configured.
+// defaultModelsTTL 는 TTL 을 설정하지 않았을 때 Copilot 모델 목록을 캐시하는 시간입니다.
+const defaultModelsTTL = 5 * time.Minute
+
+// modelCatalog caches the model list fetched from Copilot with the pooled accounts.
+// modelCatalog 는 풀의 계정으로 Copilot 에서 가져온 모델 목록을 캐시합니다.
+type modelCatalog struct {
+	mu       sync.Mutex
+	models   []map[string]any
+	fetched  time.Time
+	fetching chan struct{}
+}
+
+// errUpstreamStatus carries an upstream error response that should be relayed to the client.
+// errUpstreamStatus 는 클라이언트에 그대로 전달해야 하는 업스트림 오류 응답을 담습니다.
+type errUpstreamStatus struct {
+	resp *http.Response
+}
+
+// Error describes the upstream status.
+// Error 는 업스트림 상태 코드를 설명합니다.
+func (e *errUpstreamStatus) Error() string {
+	return fmt.Sprintf("upstream returned %d", e.resp.StatusCode)
+}
+
+// models returns the Copilot model catalog, from the cache while it is fresh.
+// models 는 Copilot 모델 목록을 반환하며, 캐시가 유효하면 캐시를 사용합니다.
+//
+// Callers using their own GitHub token bypass the cache, since their seat may offer different models.
+// Only one caller fetches at a time; the others are served the stale catalog, or wait when there is none yet.
+// 자신의 GitHub 토큰을 쓰는 호출자는 좌석마다 모델이 다를 수 있으므로 캐시를 거치지 않습니다.
+// 한 번에 한 호출자만 가져오며, 나머지는 이전 목록을 받거나 아직 목록이 없으면 기다립니다.
+func (s *ProxyServer) models(r *http.Request) ([]map[string]any, error) {
+	if oauth := s.userToken(r); oauth != "" {
+		resp, err := s.sendAsUser(r, oauth, "/models", nil)
+		if err != nil {
+			return nil, err
+		}
+		return readModels(resp)
+	}
+
+	ttl := s.config.ModelsTTL
+	if ttl <= 0 {
+		ttl = defaultModelsTTL
+	}
+	for {
+		s.catalog.mu.Lock()
+		if s.catalog.models != nil && time.Since(s.catalog.fetched) < ttl {
+			models := s.catalog.models
+			s.catalog.mu.Unlock()
+			return models, nil
+		}
+		if fetching := s.catalog.fetching; fetching != nil {
+			stale := s.catalog.models
+			s.catalog.mu.Unlock()
+			if stale != nil {
+				return stale, nil
+			}
+			select {
+			case <-fetching:
+				continue
+			case <-r.Context().Done():
+				return nil, r.Context().Err()
+			}
+		}
+		done := make(chan struct{})
+		s.catalog.fetching = done
+		s.catalog.mu.Unlock()
+
+		models, err := s.fetchModels(r)
+		s.catalog.mu.Lock()
+		if err == nil {
+			s.catalog.models = models
+			s.catalog.fetched = time.Now()
+		}
+		s.catalog.fetching = nil
+		s.catalog.mu.Unlock()
+		close(done)
+		return models, err
+	}
+}
+
+// fetchModels fetches the model catalog from Copilot with the pooled accounts.
+// fetchModels 는 풀의 계정으로 Copilot 에서 모델 목록을 가져옵니다.
+func (s *ProxyServer) fetchModels(r *http.Request) ([]map[string]any, error) {
+	resp, err := s.sendPooled(r, "/models", nil)
+	if err != nil {
+		return nil, err
+	}
+	models, err := readModels(resp)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("fetched %d model(s) from Copilot", len(models))
+	return models, nil
+}
+
+// readModels decodes an upstream model list, closing the response.
+// readModels 는 업스트림 모델 목록을 디코딩하고 응답을 닫습니다.
+func readModels(resp *http.Response) ([]map[string]any, error) {
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, &errUpstreamStatus{resp: resp}
+	}
+	defer resp.Body.Close()
+	var list struct {
+		Data []map[string]any `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("decode model list: %w", err)
+	}
+	return list.Data, nil
+}
+
+// allowedModels returns the models the caller's key policy permits.
+// allowedModels 는 호출자 키의 정책이 허용하는 모델을 반환합니다.
+func allowedModels(r *http.Request, models []map[string]any) []map[string]any {
+	key := clientKeyFrom(r.Context())
+	if key == nil || key.Policy == nil || len(key.Policy.Models) == 0 {
+		return models
+	}
+	var allowed []map[string]any
+	for _, m := range models {
+		if id, _ := m["id"].(string); key.Policy.allowsModel(id) {
+			allowed = append(allowed, m)
+		}
+	}
+	return allowed
+}
+
+// modelsHandler lists models in the caller's dialect, or looks up one model when the path carries an id.
+// modelsHandler 는 호출자 형식으로 모델 목록을 반환하며, 경로에 id 가 있으면 모델 하나를 조회합니다.
+func (s *ProxyServer) modelsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		d := dialectFrom(r.Context())
+		models, err := s.models(r)
+		if err != nil {
+			if upstream, ok := err.(*errUpstreamStatus); ok {
+				defer upstream.resp.Body.Close()
+				if err := writeUpstreamError(w, d, upstream.resp); err != nil {
+					log.Printf("models error [%s]: %v", keyName(r.Context()), err)
+				}
+				return
+			}
+			log.Printf("models error [%s]: %v", keyName(r.Context()), err)
+			s.writeForwardError(w, r, err)
+			return
+		}
+		models = allowedModels(r, models)
+
+		if id := r.PathValue("id"); id != "" {
+			for _, m := range models {
+				if m["id"] == id {
+					if d == dialectAnthropic {
+						writeJSON(w, http.StatusOK, anthropicModel(m))
+					} else {
+						writeJSON(w, http.StatusOK, openAIModel(m))
+					}
+					return
+				}
+			}
+			writeStatusError(w, d, http.StatusNotFound, fmt.Sprintf("model %q not found", id))
+			return
+		}
+
+		if d == dialectAnthropic {
+			writeAnthropicModelPage(w, r, models)
+			return
+		}
+		data := make([]map[string]any, 0, len(models))
+		for _, m := range models {
+			data = append(data, openAIModel(m))
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"object": "list", "data": data})
+	}
+}
+
+// openAIModel returns a Copilot model entry with the fields OpenAI clients require filled in.
+// openAIModel 는 OpenAI 클라이언트가 요구하는 필드를 채운 Copilot 모델 항목을 반환합니다.
+func openAIModel(m map[string]any) map[string]any {
+	out := make(map[string]any, len(m)+3)
+	for k, v := range m {
+		out[k] = v
+	}
+	out["object"] = "model"
+	if _, ok := out["created"]; !ok {
+		out["created"] = 0
+	}
+	if _, ok := out["owned_by"]; !ok {
+		owner, _ := m["vendor"].(string)
+		if owner == "" {
+			owner = "github-copilot"
+		}
+		out["owned_by"] = owner
+	}
+	return out
+}
+
+// anthropicModel converts a Copilot model entry into Anthropic's model object.
+// anthropicModel 는 Copilot 모델 항목을 Anthropic 모델 객체로 변환합니다.
+func anthropicModel(m map[string]any) map[string]any {
+	id, _ := m["id"].(string)
+	name, _ := m["name"].(string)
+	if name == "" {
+		name = id
+	}
+	return map[string]any{
+		"type":         "model",
+		"id":           id,
+		"display_name": name,
+		"created_at":   time.Unix(0, 0).UTC().Format(time.RFC3339),
+	}
+}
+
+// writeAnthropicModelPage writes one page of models honouring the limit, after_id and before_id parameters.
+// writeAnthropicModelPage 는 limit, after_id, before_id 파라미터에 맞춰 모델 목록 한 페이지를 작성합니다.
+func writeAnthropicModelPage(w http.ResponseWriter, r *http.Request, models []map[string]any) {
+	params := r.URL.Query()
+	limit := 20
+	if v := params.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 1000 {
+			writeStatusError(w, dialectAnthropic, http.StatusBadRequest, "limit must be between 1 and 1000")
+			return
+		}
+		limit = n
+	}
+
+	// A cursor that is not in the catalog gives an empty page, so a client cannot loop back to the first one.
+	// 목록에 없는 커서는 빈 페이지를 돌려주어, 클라이언트가 첫 페이지로 되돌아가 반복하지 않게 합니다.
+	start, end := 0, len(models)
+	if after := params.Get("after_id"); after != "" {
+		start = len(models)
+		if i := modelIndex(models, after); i >= 0 {
+			start = i + 1
+		}
+	}
+	if before := params.Get("before_id"); before != "" {
+		end = 0
+		if i := modelIndex(models, before); i >= 0 {
+			end = i
+		}
+	}
+	page := models[start:max(start, end)]
+	hasMore := len(page) > limit
+	if hasMore {
+		if params.Get("before_id") != "" && params.Get("after_id") == "" {
+			page = page[len(page)-limit:]
+		} else {
+			page = page[:limit]
+		}
+	}
+
+	data := make([]map[string]any, 0, len(page))
+	for _, m := range page {
+		data = append(data, anthropicModel(m))
+	}
+	var firstID, lastID any
+	if len(data) > 0 {
+		firstID, lastID = data[0]["id"], data[len(data)-1]["id"]
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"data":     data,
+		"has_more": hasMore,
+		"first_id": firstID,
+		"last_id":  lastID,
+	})
+}
+
+// modelIndex returns the position of the model with the given id, or -1.
+// modelIndex 는 주어진 id 를 가진 모델의 위치를 반환하며, 없으면 -1 을 반환합니다.
+func modelIndex(models []map[string]any, id string) int {
+	return slices.IndexFunc(models, func(m map[string]any) bool { return m["id"] == id })
+}
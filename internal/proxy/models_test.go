@@ -0,0 +1,164 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ilcm96/gh-copilot-proxy/internal/auth"
+)
+
+func TestWriteAnthropicModelPage(t *testing.T) {
+	var models []map[string]any
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		models = append(models, map[string]any{"id": id})
+	}
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantIDs    []string
+		wantMore   bool
+	}{
+		{name: "everything fits the default limit", wantIDs: []string{"a", "b", "c", "d", "e"}},
+		{name: "limit", query: "limit=2", wantIDs: []string{"a", "b"}, wantMore: true},
+		{name: "after_id", query: "after_id=b&limit=2", wantIDs: []string{"c", "d"}, wantMore: true},
+		{name: "after_id reaches the end", query: "after_id=d&limit=2", wantIDs: []string{"e"}},
+		{name: "before_id keeps the nearest models", query: "before_id=d&limit=2", wantIDs: []string{"b", "c"}, wantMore: true},
+		{name: "before_id reaches the start", query: "before_id=b", wantIDs: []string{"a"}},
+		{name: "after_id and before_id", query: "after_id=a&before_id=d", wantIDs: []string{"b", "c"}},
+		{name: "unknown after_id gives an empty page", query: "after_id=zz", wantIDs: []string{}},
+		{name: "unknown before_id gives an empty page", query: "before_id=zz&limit=2", wantIDs: []string{}},
+		{name: "limit below 1", query: "limit=0", wantStatus: http.StatusBadRequest},
+		{name: "limit above 1000", query: "limit=1001", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			writeAnthropicModelPage(rec, httptest.NewRequest(http.MethodGet, "/v1/models?"+tt.query, nil), models)
+			wantStatus := tt.wantStatus
+			if wantStatus == 0 {
+				wantStatus = http.StatusOK
+			}
+			if rec.Code != wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, wantStatus, rec.Body)
+			}
+			if wantStatus != http.StatusOK {
+				return
+			}
+			var page struct {
+				Data    []struct{ ID string }
+				HasMore bool `json:"has_more"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+				t.Fatal(err)
+			}
+			ids := []string{}
+			for _, m := range page.Data {
+				ids = append(ids, m.ID)
+			}
+			if !slices.Equal(ids, tt.wantIDs) || page.HasMore != tt.wantMore {
+				t.Errorf("page = %q has_more %t, want %q has_more %t", ids, page.HasMore, tt.wantIDs, tt.wantMore)
+			}
+		})
+	}
+}
+
+func TestModelsHandler(t *testing.T) {
+	var fetches atomic.Int32
+	var upstream *httptest.Server
+	upstream = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			fmt.Fprintf(w, `{"token":"copilot","expires_at":%d,"refresh_in":1500,"endpoints":{"api":%q}}`, time.Now().Add(time.Hour).Unix(), upstream.URL)
+		case "/models":
+			fetches.Add(1)
+			fmt.Fprint(w, `{"data":[{"id":"gpt-4o","name":"GPT-4o","vendor":"Azure OpenAI"},{"id":"claude-sonnet-4","name":"Claude Sonnet 4"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("COPILOT_OAUTH_TOKEN", "oauth")
+	t.Setenv("COPILOT_TOKEN_URL", upstream.URL+"/token")
+	pool, err := auth.NewPool(context.Background())
+	if err != nil {
+		t.Fatalf("NewPool() error = %v", err)
+	}
+	if err := pool.Setup(); err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	defer pool.Cleanup()
+	handler := NewProxyServer(pool, "k", Config{ModelsTTL: time.Hour}).Routes()
+
+	get := func(path string, anthropic bool) (int, map[string]any) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer k")
+		if anthropic {
+			req.Header.Set("anthropic-version", "2023-06-01")
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		var body map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("GET %s returned invalid JSON %s: %v", path, rec.Body, err)
+		}
+		return rec.Code, body
+	}
+
+	status, body := get("/v1/models", false)
+	if status != http.StatusOK || body["object"] != "list" {
+		t.Fatalf("GET /v1/models = %d %v, want an OpenAI list", status, body)
+	}
+	if owner := nestedValue(body, "data", 0, "owned_by"); owner != "Azure OpenAI" {
+		t.Errorf("owned_by = %v, want the vendor", owner)
+	}
+
+	status, body = get("/v1/models?limit=1", true)
+	if status != http.StatusOK || body["has_more"] != true || nestedValue(body, "data", 0, "type") != "model" {
+		t.Errorf("GET /v1/models as Anthropic = %d %v, want one model page with more", status, body)
+	}
+	if name := nestedValue(body, "data", 0, "display_name"); name != "GPT-4o" {
+		t.Errorf("display_name = %v, want GPT-4o", name)
+	}
+
+	status, body = get("/v1/models/claude-sonnet-4", false)
+	if status != http.StatusOK || body["id"] != "claude-sonnet-4" {
+		t.Errorf("GET /v1/models/claude-sonnet-4 = %d %v", status, body)
+	}
+	if status, _ = get("/v1/models/unknown", true); status != http.StatusNotFound {
+		t.Errorf("GET /v1/models/unknown = %d, want 404", status)
+	}
+
+	// Every request above was served from one upstream fetch.
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("upstream /models fetched %d time(s), want 1", n)
+	}
+}
+
+// nestedValue walks maps and slices decoded from JSON, returning nil when the path does not exist.
+func nestedValue(v any, path ...any) any {
+	for _, p := range path {
+		switch key := p.(type) {
+		case string:
+			m, _ := v.(map[string]any)
+			v = m[key]
+		case int:
+			s, _ := v.([]any)
+			if key >= len(s) {
+				return nil
+			}
+			v = s[key]
+		}
+	}
+	return v
+}
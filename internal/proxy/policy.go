@@ -0,0 +1,294 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Policy restricts what requests a client key may send; the zero value allows everything.
+// Policy 는 클라이언트 키가 보낼 수 있는 요청을 제한하며, 0 값은 모든 요청을 허용합니다.
+type Policy struct {
+	// Models lists allowed model names; entries may use path.Match patterns such as "gpt-4o*".
+	// Models 는 허용할 모델 이름 목록이며, "gpt-4o*" 같은 path.Match 패턴을 쓸 수 있습니다.
+	Models []string `json:"models,omitempty"`
+	// MaxTokens caps max_tokens and its per-endpoint variants; generation requests that omit it are sent with the cap.
+	// MaxTokens 는 max_tokens 와 엔드포인트별 대응 필드의 상한이며, 값을 생략한 생성 요청에는 상한을 넣어 보냅니다.
+	MaxTokens int `json:"max_tokens,omitempty"`
+	// DenyTools rejects requests that declare tools or functions.
+	// DenyTools 는 도구나 함수를 선언한 요청을 거부합니다.
+	DenyTools bool `json:"deny_tools,omitempty"`
+	// DenyVision rejects requests that contain images.
+	// DenyVision 는 이미지가 포함된 요청을 거부합니다.
+	DenyVision bool `json:"deny_vision,omitempty"`
+}
+
+// errPolicyBody reports a request body the policy cannot read, which is a client error rather than a violation.
+// errPolicyBody 는 정책이 읽을 수 없는 요청 본문으로, 정책 위반이 아닌 클라이언트 오류입니다.
+var errPolicyBody = errors.New("request body is not valid JSON")
+
+// endpoint is the kind of request a route serves, which decides the field the max_tokens cap applies to.
+// endpoint 는 라우트가 처리하는 요청의 종류이며, max_tokens 상한을 적용할 필드를 정합니다.
+type endpoint int
+
+const (
+	// endpointChat is chat/completions and Anthropic messages, capped through max_tokens.
+	// endpointChat 는 chat/completions 와 Anthropic messages 이며, max_tokens 로 상한을 둡니다.
+	endpointChat endpoint = iota
+	// endpointEmbeddings generates no output tokens, so the cap does not apply.
+	// endpointEmbeddings 는 출력 토큰을 만들지 않으므로 상한을 적용하지 않습니다.
+	endpointEmbeddings
+	// endpointResponses is the Responses API, capped through max_output_tokens.
+	// endpointResponses 는 Responses API 이며, max_output_tokens 로 상한을 둡니다.
+	endpointResponses
+	// endpointCompletions is the OpenAI legacy completions API, capped through max_tokens.
+	// endpointCompletions 는 OpenAI 레거시 completions API 이며, max_tokens 로 상한을 둡니다.
+	endpointCompletions
+	// endpointComplete is the Anthropic legacy complete API, capped through max_tokens_to_sample.
+	// endpointComplete 는 Anthropic 레거시 complete API 이며, max_tokens_to_sample 로 상한을 둡니다.
+	endpointComplete
+	// endpointCountTokens only counts input, so the cap does not apply.
+	// endpointCountTokens 는 입력만 세므로 상한을 적용하지 않습니다.
+	endpointCountTokens
+	// endpointGemini is Gemini generateContent, capped through generationConfig.maxOutputTokens.
+	// endpointGemini 는 Gemini generateContent 이며, generationConfig.maxOutputTokens 로 상한을 둡니다.
+	endpointGemini
+)
+
+// policyRequest holds the request fields a policy looks at, in every dialect.
+// policyRequest 는 모든 형식에서 정책이 확인하는 요청 필드를 담습니다.
+type policyRequest struct {
+	Model               string            `json:"model"`
+	MaxTokens           *int              `json:"max_tokens"`
+	MaxCompletionTokens *int              `json:"max_completion_tokens"`
+	MaxOutputTokens     *int              `json:"max_output_tokens"`
+	MaxTokensToSample   *int              `json:"max_tokens_to_sample"`
+	Tools               []json.RawMessage `json:"tools"`
+	Functions           []json.RawMessage `json:"functions"`
+	GenerationConfig    struct {
+		MaxOutputTokens *int `json:"maxOutputTokens"`
+	} `json:"generationConfig"`
+}
+
+// outputLimits returns the request's output token limits that the endpoint reads; nil means the cap does not apply.
+// outputLimits 는 엔드포인트가 읽는 요청의 출력 토큰 한도를 반환하며, nil 이면 상한을 적용하지 않습니다.
+func (e endpoint) outputLimits(req *policyRequest) []*int {
+	switch e {
+	case endpointChat:
+		return []*int{req.MaxTokens, req.MaxCompletionTokens}
+	case endpointResponses:
+		return []*int{req.MaxOutputTokens}
+	case endpointCompletions:
+		return []*int{req.MaxTokens}
+	case endpointComplete:
+		return []*int{req.MaxTokensToSample}
+	case endpointGemini:
+		return []*int{req.GenerationConfig.MaxOutputTokens}
+	}
+	return nil
+}
+
+// withPolicy returns HTTP middleware that enforces the caller's key policy before the request is forwarded.
+// withPolicy 는 요청을 전달하기 전에 호출자 키의 정책을 적용하는 HTTP 미들웨어를 반환합니다.
+func (s *ProxyServer) withPolicy(d dialect, e endpoint, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := clientKeyFrom(r.Context())
+		if key == nil || key.Policy == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeDialectError(w, d, http.StatusBadRequest, "invalid_request_error", "failed to read request body")
+			return
+		}
+		model := ""
+		if e == endpointGemini {
+			model, _ = geminiTarget(r)
+		}
+		body, err = key.Policy.apply(d, e, body, model)
+		if errors.Is(err, errPolicyBody) {
+			writeDialectError(w, d, http.StatusBadRequest, "invalid_request_error", err.Error())
+			return
+		}
+		if err != nil {
+			log.Printf("policy [%s]: %v", key.Name, err)
+			writeDialectError(w, d, http.StatusForbidden, "permission_error", err.Error())
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// apply checks body against the policy and returns it, with max_tokens filled in when the cap applies.
+// apply 는 본문을 정책과 대조한 뒤 반환하며, 상한이 적용되면 max_tokens 를 채워 넣습니다.
+//
+// model overrides the body's model for routes that name the model in the path.
+// model 은 경로에 모델 이름이 있는 라우트에서 본문의 모델 대신 사용합니다.
+func (p *Policy) apply(d dialect, e endpoint, body []byte, model string) ([]byte, error) {
+	var req policyRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, errPolicyBody
+	}
+	if model != "" {
+		req.Model = model
+	}
+
+	if len(p.Models) > 0 && !p.allowsModel(req.Model) {
+		return nil, fmt.Errorf("model %q is not allowed for this key", req.Model)
+	}
+	if p.DenyTools && (len(req.Tools) > 0 || len(req.Functions) > 0) {
+		return nil, fmt.Errorf("tools are not allowed for this key")
+	}
+	if p.DenyVision && hasImages(d, body) {
+		return nil, fmt.Errorf("image inputs are not allowed for this key")
+	}
+	limits := e.outputLimits(&req)
+	if p.MaxTokens <= 0 || limits == nil {
+		return body, nil
+	}
+
+	for _, limit := range limits {
+		if limit != nil && *limit > p.MaxTokens {
+			return nil, fmt.Errorf("max_tokens %d exceeds this key's limit of %d", *limit, p.MaxTokens)
+		}
+	}
+	if slices.ContainsFunc(limits, func(limit *int) bool { return limit != nil }) {
+		return body, nil
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, errPolicyBody
+	}
+	switch e {
+	case endpointGemini:
+		config, _ := payload["generationConfig"].(map[string]any)
+		if config == nil {
+			config = make(map[string]any)
+		}
+		config["maxOutputTokens"] = p.MaxTokens
+		payload["generationConfig"] = config
+	case endpointResponses:
+		payload["max_output_tokens"] = p.MaxTokens
+	case endpointComplete:
+		payload["max_tokens_to_sample"] = p.MaxTokens
+	default:
+		payload["max_tokens"] = p.MaxTokens
+	}
+	return json.Marshal(payload)
+}
+
+// allowsModel reports whether model matches one of the allowed names or patterns.
+// allowsModel 는 model 이 허용된 이름이나 패턴 중 하나와 일치하는지 확인합니다.
+func (p *Policy) allowsModel(model string) bool {
+	for _, pattern := range p.Models {
+		if ok, err := path.Match(pattern, model); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hasImages checks for image content in a request body of the given dialect.
+// hasImages 는 지정한 형식의 요청 본문에 이미지 콘텐츠가 있는지 검사합니다.
+func hasImages(d dialect, body []byte) bool {
+	switch d {
+	case dialectOpenAI:
+		return hasVisionContent(body) || hasInputImages(body)
+	case dialectGemini:
+		return hasGeminiImages(body)
+	}
+	var payload struct {
+		Messages []struct {
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return false
+	}
+	for _, msg := range payload.Messages {
+		var blocks []struct {
+			Type string `json:"type"`
+		}
+		if json.Unmarshal(msg.Content, &blocks) != nil {
+			continue
+		}
+		for _, block := range blocks {
+			if block.Type == "image" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasInputImages checks for input_image parts in the input items of a Responses API request.
+// hasInputImages 는 Responses API 요청의 입력 항목에 input_image 파트가 있는지 검사합니다.
+func hasInputImages(body []byte) bool {
+	var payload struct {
+		Input json.RawMessage `json:"input"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return false
+	}
+	var items []struct {
+		Content json.RawMessage `json:"content"`
+	}
+	if json.Unmarshal(payload.Input, &items) != nil {
+		return false
+	}
+	for _, item := range items {
+		var parts []struct {
+			Type string `json:"type"`
+		}
+		if json.Unmarshal(item.Content, &parts) != nil {
+			continue
+		}
+		for _, part := range parts {
+			if part.Type == "input_image" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasGeminiImages checks for inline or file image parts in a Gemini request.
+// hasGeminiImages 는 Gemini 요청에 인라인 또는 파일 이미지 파트가 있는지 검사합니다.
+func hasGeminiImages(body []byte) bool {
+	var payload struct {
+		Contents []struct {
+			Parts []struct {
+				InlineData *struct {
+					MimeType string `json:"mimeType"`
+				} `json:"inlineData"`
+				FileData *struct {
+					MimeType string `json:"mimeType"`
+				} `json:"fileData"`
+			} `json:"parts"`
+		} `json:"contents"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return false
+	}
+	for _, content := range payload.Contents {
+		for _, part := range content.Parts {
+			if part.InlineData != nil && strings.HasPrefix(part.InlineData.MimeType, "image/") {
+				return true
+			}
+			if part.FileData != nil && strings.HasPrefix(part.FileData.MimeType, "image/") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,190 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPolicyApply(t *testing.T) {
+	capped := &Policy{MaxTokens: 100}
+	tests := []struct {
+		name    string
+		policy  *Policy
+		dialect dialect
+		route   endpoint
+		body    string
+		model   string
+		want    string
+		wantErr bool
+		badBody bool
+	}{
+		{
+			name:   "chat without max_tokens gets the cap",
+			policy: capped, dialect: dialectOpenAI, route: endpointChat,
+			body: `{"model":"gpt-4o","messages":[]}`,
+			want: `{"model":"gpt-4o","messages":[],"max_tokens":100}`,
+		},
+		{
+			name:   "chat within the cap is unchanged",
+			policy: capped, dialect: dialectOpenAI, route: endpointChat,
+			body: `{"model":"gpt-4o","max_completion_tokens":50}`,
+			want: `{"model":"gpt-4o","max_completion_tokens":50}`,
+		},
+		{
+			name:   "chat above the cap is rejected",
+			policy: capped, dialect: dialectOpenAI, route: endpointChat,
+			body:    `{"model":"gpt-4o","max_completion_tokens":500}`,
+			wantErr: true,
+		},
+		{
+			name:   "anthropic messages above the cap is rejected",
+			policy: capped, dialect: dialectAnthropic, route: endpointChat,
+			body:    `{"model":"claude-sonnet-4","max_tokens":4096}`,
+			wantErr: true,
+		},
+		{
+			name:   "embeddings are not capped",
+			policy: capped, dialect: dialectOpenAI, route: endpointEmbeddings,
+			body: `{"model":"text-embedding-3-small","input":"hi"}`,
+			want: `{"model":"text-embedding-3-small","input":"hi"}`,
+		},
+		{
+			name:   "responses get max_output_tokens",
+			policy: capped, dialect: dialectOpenAI, route: endpointResponses,
+			body: `{"model":"gpt-4o","input":"hi"}`,
+			want: `{"model":"gpt-4o","input":"hi","max_output_tokens":100}`,
+		},
+		{
+			name:   "responses above the cap is rejected",
+			policy: capped, dialect: dialectOpenAI, route: endpointResponses,
+			body:    `{"model":"gpt-4o","input":"hi","max_output_tokens":101}`,
+			wantErr: true,
+		},
+		{
+			name:   "legacy completions get max_tokens",
+			policy: capped, dialect: dialectOpenAI, route: endpointCompletions,
+			body: `{"model":"gpt-4o","prompt":"hi"}`,
+			want: `{"model":"gpt-4o","prompt":"hi","max_tokens":100}`,
+		},
+		{
+			name:   "legacy complete gets max_tokens_to_sample",
+			policy: capped, dialect: dialectAnthropic, route: endpointComplete,
+			body: `{"model":"claude-2","prompt":"\n\nHuman: hi\n\nAssistant:"}`,
+			want: `{"model":"claude-2","prompt":"\n\nHuman: hi\n\nAssistant:","max_tokens_to_sample":100}`,
+		},
+		{
+			name:   "count_tokens is not capped",
+			policy: capped, dialect: dialectAnthropic, route: endpointCountTokens,
+			body: `{"model":"claude-sonnet-4","messages":[]}`,
+			want: `{"model":"claude-sonnet-4","messages":[]}`,
+		},
+		{
+			name:   "gemini gets generationConfig.maxOutputTokens",
+			policy: capped, dialect: dialectGemini, route: endpointGemini,
+			body: `{"contents":[],"generationConfig":{"temperature":0.5}}`, model: "gemini-2.5-pro",
+			want: `{"contents":[],"generationConfig":{"temperature":0.5,"maxOutputTokens":100}}`,
+		},
+		{
+			name:   "gemini above the cap is rejected",
+			policy: capped, dialect: dialectGemini, route: endpointGemini,
+			body: `{"contents":[],"generationConfig":{"maxOutputTokens":1000}}`, model: "gemini-2.5-pro",
+			wantErr: true,
+		},
+		{
+			name:   "model pattern allows a match",
+			policy: &Policy{Models: []string{"gpt-4o*"}}, dialect: dialectOpenAI, route: endpointChat,
+			body: `{"model":"gpt-4o-mini"}`,
+			want: `{"model":"gpt-4o-mini"}`,
+		},
+		{
+			name:   "model outside the list is rejected",
+			policy: &Policy{Models: []string{"gpt-4o*"}}, dialect: dialectOpenAI, route: endpointChat,
+			body:    `{"model":"claude-sonnet-4"}`,
+			wantErr: true,
+		},
+		{
+			name:   "gemini model comes from the path",
+			policy: &Policy{Models: []string{"gpt-4o"}}, dialect: dialectGemini, route: endpointGemini,
+			body: `{"model":"gpt-4o","contents":[]}`, model: "gemini-2.5-pro",
+			wantErr: true,
+		},
+		{
+			name:   "tools are rejected",
+			policy: &Policy{DenyTools: true}, dialect: dialectOpenAI, route: endpointChat,
+			body:    `{"model":"gpt-4o","tools":[{"type":"function"}]}`,
+			wantErr: true,
+		},
+		{
+			name:   "legacy functions are rejected",
+			policy: &Policy{DenyTools: true}, dialect: dialectOpenAI, route: endpointChat,
+			body:    `{"model":"gpt-4o","functions":[{"name":"f"}]}`,
+			wantErr: true,
+		},
+		{
+			name:   "openai image is rejected",
+			policy: &Policy{DenyVision: true}, dialect: dialectOpenAI, route: endpointChat,
+			body:    `{"model":"gpt-4o","messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"data:image/png;base64,AA"}}]}]}`,
+			wantErr: true,
+		},
+		{
+			name:   "responses input image is rejected",
+			policy: &Policy{DenyVision: true}, dialect: dialectOpenAI, route: endpointResponses,
+			body:    `{"model":"gpt-4o","input":[{"role":"user","content":[{"type":"input_image","image_url":"data:image/png;base64,AA"}]}]}`,
+			wantErr: true,
+		},
+		{
+			name:   "anthropic image is rejected",
+			policy: &Policy{DenyVision: true}, dialect: dialectAnthropic, route: endpointChat,
+			body:    `{"model":"claude-sonnet-4","messages":[{"role":"user","content":[{"type":"image","source":{"type":"base64","media_type":"image/png","data":"AA"}}]}]}`,
+			wantErr: true,
+		},
+		{
+			name:   "gemini inline image is rejected",
+			policy: &Policy{DenyVision: true}, dialect: dialectGemini, route: endpointGemini,
+			body: `{"contents":[{"role":"user","parts":[{"inlineData":{"mimeType":"image/png","data":"AA"}}]}]}`, model: "gemini-2.5-pro",
+			wantErr: true,
+		},
+		{
+			name:   "text only passes the vision check",
+			policy: &Policy{DenyVision: true}, dialect: dialectOpenAI, route: endpointChat,
+			body: `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`,
+			want: `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`,
+		},
+		{
+			name:   "invalid JSON is rejected",
+			policy: capped, dialect: dialectOpenAI, route: endpointChat,
+			body:    `{`,
+			wantErr: true,
+			badBody: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.policy.apply(tt.dialect, tt.route, []byte(tt.body), tt.model)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("apply() = %s, want an error", got)
+				}
+				if errors.Is(err, errPolicyBody) != tt.badBody {
+					t.Errorf("apply() error = %v, want a client error %t", err, tt.badBody)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("apply() error = %v", err)
+			}
+			var gotJSON, wantJSON any
+			if err := json.Unmarshal(got, &gotJSON); err != nil {
+				t.Fatalf("apply() returned invalid JSON %s: %v", got, err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &wantJSON); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(gotJSON, wantJSON) {
+				t.Errorf("apply() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
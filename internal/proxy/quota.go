@@ -0,0 +1,157 @@
+package proxy
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Quota holds the daily and monthly usage quotas of a client key.
+// Quota 는 클라이언트 키의 일별, 월별 사용량 할당량을 담습니다.
+type Quota struct {
+	Daily   QuotaLimit `json:"daily,omitzero"`
+	Monthly QuotaLimit `json:"monthly,omitzero"`
+}
+
+// QuotaLimit caps requests and tokens over one period; zero disables a limit.
+// QuotaLimit 는 한 기간 동안의 요청 수와 토큰 수를 제한하며, 0 이면 해당 제한을 끕니다.
+//
+// Requests counts successful upstream requests, one each; it is not Copilot's premium request count,
+// which weights each model by a multiplier.
+// Requests 는 성공한 업스트림 요청을 한 건씩 세며, 모델마다 배수를 적용하는 Copilot 의 프리미엄 요청 수와는 다릅니다.
+//
+// Hard limits reject further requests; soft limits only log and add a warning header.
+// 하드 제한은 이후 요청을 거부하고, 소프트 제한은 로그와 경고 헤더만 남깁니다.
+type QuotaLimit struct {
+	Requests     int64 `json:"requests,omitempty"`
+	Tokens       int64 `json:"tokens,omitempty"`
+	SoftRequests int64 `json:"soft_requests,omitempty"`
+	SoftTokens   int64 `json:"soft_tokens,omitempty"`
+}
+
+// quotaWarningHeader names the response header that reports soft quota crossings.
+// quotaWarningHeader 는 소프트 할당량 초과를 알리는 응답 헤더 이름입니다.
+const quotaWarningHeader = "X-Quota-Warning"
+
+// quotaCheck is one limit compared against the usage of a period.
+// quotaCheck 는 한 기간의 사용량과 비교하는 제한 하나입니다.
+type quotaCheck struct {
+	period string
+	metric string
+	used   int64
+	limit  int64
+	soft   bool
+	reset  time.Time
+}
+
+// String describes the check for logs, errors and headers.
+// String 는 로그, 오류, 헤더에 쓸 제한 설명을 반환합니다.
+func (c quotaCheck) String() string {
+	return fmt.Sprintf("%s %s %d/%d", c.period, c.metric, c.used, c.limit)
+}
+
+// quotaWarnings remembers which soft quota crossings have already been logged.
+// quotaWarnings 는 이미 로그로 남긴 소프트 할당량 초과를 기억합니다.
+type quotaWarnings struct {
+	mu     sync.Mutex
+	logged map[string]bool
+}
+
+// once reports whether id is seen for the first time.
+// once 는 id 를 처음 보는지 확인합니다.
+func (q *quotaWarnings) once(id string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.logged == nil {
+		q.logged = make(map[string]bool)
+	}
+	if q.logged[id] {
+		return false
+	}
+	q.logged[id] = true
+	return true
+}
+
+// withQuota returns HTTP middleware that enforces the caller's usage quotas from the usage ledger.
+// withQuota 는 사용량 기록을 바탕으로 호출자의 사용량 할당량을 적용하는 HTTP 미들웨어를 반환합니다.
+func (s *ProxyServer) withQuota(d dialect, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := clientKeyFrom(r.Context())
+		if key == nil || key.Quota == nil || s.config.Usage == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var warnings []string
+		for _, check := range s.quotaChecks(key.Name, key.Quota, time.Now()) {
+			if check.used < check.limit {
+				continue
+			}
+			if !check.soft {
+				log.Printf("quota [%s]: %s exceeded", key.Name, check)
+				w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(check.reset).Seconds())+1))
+				writeDialectError(w, d, http.StatusTooManyRequests, quotaErrorType(d),
+					fmt.Sprintf("%s quota exceeded for this key (%s), resets at %s", check.period, check, check.reset.Format(time.RFC3339)))
+				return
+			}
+			if s.quotaWarnings.once(key.Name + " " + check.period + " " + check.metric + " " + check.reset.Format(time.DateOnly)) {
+				log.Printf("quota [%s]: soft %s crossed", key.Name, check)
+			}
+			warnings = append(warnings, check.String())
+		}
+		if len(warnings) > 0 {
+			w.Header().Set(quotaWarningHeader, "soft quota reached: "+strings.Join(warnings, ", "))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// quotaChecks returns every configured limit of the quota with the key's usage in its period.
+// quotaChecks 는 할당량에 설정된 모든 제한을 해당 기간의 키 사용량과 함께 반환합니다.
+func (s *ProxyServer) quotaChecks(name string, quota *Quota, now time.Time) []quotaCheck {
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	var checks []quotaCheck
+	for _, p := range []struct {
+		name  string
+		limit QuotaLimit
+		start time.Time
+		reset time.Time
+	}{
+		{"daily", quota.Daily, day, day.AddDate(0, 0, 1)},
+		{"monthly", quota.Monthly, month, month.AddDate(0, 1, 0)},
+	} {
+		if p.limit == (QuotaLimit{}) {
+			continue
+		}
+		used := s.config.Usage.Since(name, p.start)
+		requests := used.Requests - used.Errors
+		for _, c := range []quotaCheck{
+			{metric: "requests", used: requests, limit: p.limit.Requests},
+			{metric: "tokens", used: used.Tokens(), limit: p.limit.Tokens},
+			{metric: "requests", used: requests, limit: p.limit.SoftRequests, soft: true},
+			{metric: "tokens", used: used.Tokens(), limit: p.limit.SoftTokens, soft: true},
+		} {
+			if c.limit > 0 {
+				c.period, c.reset = p.name, p.reset
+				checks = append(checks, c)
+			}
+		}
+	}
+	return checks
+}
+
+// quotaErrorType returns the error type each dialect uses for an exhausted quota.
+// quotaErrorType 는 각 형식이 할당량 소진에 사용하는 오류 유형을 반환합니다.
+func quotaErrorType(d dialect) string {
+	if d == dialectAnthropic {
+		return "rate_limit_error"
+	}
+	return "insufficient_quota"
+}
@@ -0,0 +1,44 @@
+package proxy
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ilcm96/gh-copilot-proxy/internal/usage"
+)
+
+func TestQuotaChecksCountSuccessfulRequests(t *testing.T) {
+	ledger, err := usage.Open(filepath.Join(t.TempDir(), "ledger.jsonl"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer ledger.Close()
+
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	for _, rec := range []usage.Record{
+		{Time: now, Key: "team", Model: "gpt-4o", Status: 200, InputTokens: 30, OutputTokens: 10},
+		{Time: now, Key: "team", Model: "gpt-4o", Status: 502},
+		{Time: now, Key: "team", Model: "gpt-4o", Status: 0},
+		// Earlier in the month counts towards the monthly quota only.
+		{Time: now.AddDate(0, 0, -3), Key: "team", Model: "gpt-4o", Status: 200, InputTokens: 100},
+		{Time: now, Key: "other", Model: "gpt-4o", Status: 200, InputTokens: 1000},
+	} {
+		if err := ledger.Append(rec); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	s := &ProxyServer{config: Config{Usage: ledger}}
+	quota := &Quota{Daily: QuotaLimit{Requests: 1, SoftTokens: 50}, Monthly: QuotaLimit{Requests: 10, Tokens: 1000}}
+	var got []string
+	for _, c := range s.quotaChecks("team", quota, now) {
+		got = append(got, c.String())
+	}
+	// Failed requests use no quota, and other keys do not count.
+	want := []string{"daily requests 1/1", "daily tokens 40/50", "monthly requests 2/10", "monthly tokens 140/1000"}
+	if !slices.Equal(got, want) {
+		t.Errorf("quotaChecks() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,208 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit is a per-key budget of requests and estimated tokens per minute; zero disables a limit.
+// RateLimit 는 키별 분당 요청 수와 추정 토큰 수 한도이며, 0 이면 해당 한도를 끕니다.
+type RateLimit struct {
+	RequestsPerMinute int `json:"requests_per_minute,omitempty"`
+	TokensPerMinute   int `json:"tokens_per_minute,omitempty"`
+}
+
+// bucket is a token bucket that refills continuously up to its per-minute capacity.
+// bucket 는 분당 용량까지 연속적으로 다시 채워지는 토큰 버킷입니다.
+type bucket struct {
+	capacity float64
+	level    float64
+	updated  time.Time
+}
+
+// newBucket returns a full bucket holding perMinute units.
+// newBucket 는 perMinute 만큼 가득 찬 버킷을 반환합니다.
+func newBucket(perMinute int, now time.Time) *bucket {
+	return &bucket{capacity: float64(perMinute), level: float64(perMinute), updated: now}
+}
+
+// refill adds the units earned since the last update.
+// refill 는 마지막 갱신 이후 쌓인 만큼을 채웁니다.
+func (b *bucket) refill(now time.Time) {
+	b.level = math.Min(b.capacity, b.level+now.Sub(b.updated).Minutes()*b.capacity)
+	b.updated = now
+}
+
+// wait returns how long until cost units are available; cost is capped at the capacity so large requests can still pass.
+// wait 는 cost 만큼 사용할 수 있을 때까지의 시간을 반환하며, 큰 요청도 통과할 수 있도록 cost 는 용량으로 제한됩니다.
+func (b *bucket) wait(cost float64) time.Duration {
+	cost = math.Min(cost, b.capacity)
+	if b.level >= cost {
+		return 0
+	}
+	return time.Duration((cost - b.level) / b.capacity * float64(time.Minute))
+}
+
+// untilFull returns how long until the bucket is full again.
+// untilFull 는 버킷이 다시 가득 찰 때까지의 시간을 반환합니다.
+func (b *bucket) untilFull() time.Duration {
+	return time.Duration((b.capacity - b.level) / b.capacity * float64(time.Minute))
+}
+
+// keyBuckets holds the buckets of one client key and the limit they were built from.
+// keyBuckets 는 클라이언트 키 하나의 버킷들과 그 기준이 된 한도를 담습니다.
+type keyBuckets struct {
+	limit    RateLimit
+	requests *bucket
+	tokens   *bucket
+}
+
+// rateLimiter tracks token buckets per client key name.
+// rateLimiter 는 클라이언트 키 이름별 토큰 버킷을 추적합니다.
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*keyBuckets
+}
+
+// rateDecision is the outcome of a rate limit check and the state to report in headers.
+// rateDecision 는 요청 한도 확인 결과와 헤더로 알릴 상태입니다.
+type rateDecision struct {
+	limit      RateLimit
+	retryAfter time.Duration
+	requests   bucket
+	tokens     bucket
+}
+
+// take spends one request and cost tokens from the key's buckets, or reports how long to wait without spending anything.
+// take 는 키의 버킷에서 요청 하나와 cost 만큼의 토큰을 사용하며, 부족하면 아무것도 쓰지 않고 기다릴 시간을 알립니다.
+func (l *rateLimiter) take(name string, limit RateLimit, cost int, now time.Time) rateDecision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.buckets == nil {
+		l.buckets = make(map[string]*keyBuckets)
+	}
+	kb, ok := l.buckets[name]
+	if !ok || kb.limit != limit {
+		kb = &keyBuckets{limit: limit}
+		if limit.RequestsPerMinute > 0 {
+			kb.requests = newBucket(limit.RequestsPerMinute, now)
+		}
+		if limit.TokensPerMinute > 0 {
+			kb.tokens = newBucket(limit.TokensPerMinute, now)
+		}
+		l.buckets[name] = kb
+	}
+
+	decision := rateDecision{limit: limit}
+	if kb.requests != nil {
+		kb.requests.refill(now)
+		decision.retryAfter = max(decision.retryAfter, kb.requests.wait(1))
+	}
+	if kb.tokens != nil {
+		kb.tokens.refill(now)
+		decision.retryAfter = max(decision.retryAfter, kb.tokens.wait(float64(cost)))
+	}
+	if decision.retryAfter == 0 {
+		if kb.requests != nil {
+			kb.requests.level -= 1
+		}
+		if kb.tokens != nil {
+			kb.tokens.level -= math.Min(float64(cost), kb.tokens.capacity)
+		}
+	}
+	if kb.requests != nil {
+		decision.requests = *kb.requests
+	}
+	if kb.tokens != nil {
+		decision.tokens = *kb.tokens
+	}
+	return decision
+}
+
+// withRateLimit returns HTTP middleware that applies the caller's request and token rate limits.
+// withRateLimit 는 호출자의 요청 수와 토큰 수 한도를 적용하는 HTTP 미들웨어를 반환합니다.
+func (s *ProxyServer) withRateLimit(d dialect, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := clientKeyFrom(r.Context())
+		limit := s.config.RateLimit
+		if key != nil && key.RateLimit != nil {
+			limit = *key.RateLimit
+		}
+		if key == nil || limit == (RateLimit{}) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cost := 0
+		if limit.TokensPerMinute > 0 {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				writeDialectError(w, d, http.StatusBadRequest, "invalid_request_error", "failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			cost = estimateRequestTokens(body)
+		}
+
+		decision := s.limiter.take(key.Name, limit, cost, time.Now())
+		if decision.retryAfter == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		log.Printf("rate limit [%s]: retry after %s", key.Name, decision.retryAfter)
+		decision.writeHeaders(w.Header(), d, time.Now())
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(decision.retryAfter.Seconds()))))
+		writeDialectError(w, d, http.StatusTooManyRequests, "rate_limit_error", "rate limit exceeded for this key, retry after "+decision.retryAfter.Round(time.Second).String())
+	})
+}
+
+// writeHeaders reports the bucket state using the rate limit headers of the dialect.
+// writeHeaders 는 해당 형식의 요청 한도 헤더로 버킷 상태를 알립니다.
+func (d rateDecision) writeHeaders(h http.Header, dl dialect, now time.Time) {
+	for _, b := range []struct {
+		name   string
+		limit  int
+		bucket bucket
+	}{
+		{"requests", d.limit.RequestsPerMinute, d.requests},
+		{"tokens", d.limit.TokensPerMinute, d.tokens},
+	} {
+		if b.limit <= 0 {
+			continue
+		}
+		remaining := strconv.Itoa(max(0, int(b.bucket.level)))
+		reset := b.bucket.untilFull()
+		if dl == dialectAnthropic {
+			h.Set("anthropic-ratelimit-"+b.name+"-limit", strconv.Itoa(b.limit))
+			h.Set("anthropic-ratelimit-"+b.name+"-remaining", remaining)
+			h.Set("anthropic-ratelimit-"+b.name+"-reset", now.Add(reset).UTC().Format(time.RFC3339))
+			continue
+		}
+		h.Set("x-ratelimit-limit-"+b.name, strconv.Itoa(b.limit))
+		h.Set("x-ratelimit-remaining-"+b.name, remaining)
+		h.Set("x-ratelimit-reset-"+b.name, reset.Round(time.Millisecond).String())
+	}
+}
+
+// estimateRequestTokens roughly estimates the tokens a request will consume: about four bytes per prompt token plus the requested output.
+// estimateRequestTokens 는 요청이 사용할 토큰 수를 대략 추정합니다. 프롬프트는 4바이트당 약 1토큰으로 보고 요청한 출력 토큰 수를 더합니다.
+func estimateRequestTokens(body []byte) int {
+	var req struct {
+		MaxTokens           int `json:"max_tokens"`
+		MaxCompletionTokens int `json:"max_completion_tokens"`
+		MaxOutputTokens     int `json:"max_output_tokens"`
+		MaxTokensToSample   int `json:"max_tokens_to_sample"`
+		GenerationConfig    struct {
+			MaxOutputTokens int `json:"maxOutputTokens"`
+		} `json:"generationConfig"`
+	}
+	_ = json.Unmarshal(body, &req)
+	return len(body)/4 + max(req.MaxTokens, req.MaxCompletionTokens, req.MaxOutputTokens, req.MaxTokensToSample, req.GenerationConfig.MaxOutputTokens)
+}
@@ -0,0 +1,100 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/ilcm96/gh-copilot-proxy/internal/adapter"
+)
+
+// maxStoredResponses bounds how many responses are kept in memory for previous_response_id.
+// maxStoredResponses 는 previous_response_id 를 위해 메모리에 보관하는 응답 수의 상한입니다.
+const maxStoredResponses = 1000
+
+// storedResponse is the conversation up to and including one response, as chat/completions messages.
+// storedResponse 는 응답 하나까지의 대화를 chat/completions 메시지로 담습니다.
+type storedResponse struct {
+	key      string
+	messages []any
+}
+
+// responseStore keeps recent conversations so later requests can continue them by response id.
+// responseStore 는 이후 요청이 응답 id 로 이어갈 수 있도록 최근 대화를 보관합니다.
+type responseStore struct {
+	mu        sync.Mutex
+	responses map[string]storedResponse
+	order     []string
+}
+
+// get returns the conversation stored under id, if it belongs to the same client key.
+// get 는 id 로 저장된 대화가 같은 클라이언트 키의 것이면 반환합니다.
+func (s *responseStore) get(id, key string) ([]any, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.responses[id]
+	if !ok || stored.key != key {
+		return nil, false
+	}
+	return stored.messages, true
+}
+
+// put stores a conversation under id, evicting the oldest one when full.
+// put 는 대화를 id 로 저장하며, 가득 차면 가장 오래된 대화를 지웁니다.
+func (s *responseStore) put(id, key string, messages []any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.responses == nil {
+		s.responses = make(map[string]storedResponse)
+	}
+	if len(s.order) >= maxStoredResponses {
+		delete(s.responses, s.order[0])
+		s.order = s.order[1:]
+	}
+	s.responses[id] = storedResponse{key: key, messages: messages}
+	s.order = append(s.order, id)
+}
+
+// responsesHandler creates a handler for the OpenAI Responses API, served on top of chat/completions.
+// responsesHandler 는 chat/completions 위에서 동작하는 OpenAI Responses API 핸들러를 생성합니다.
+func (s *ProxyServer) responsesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := adapter.NewResponseID()
+		key := keyName(r.Context())
+		var request map[string]any
+		var conversation []any
+		opts := &ProxyOptions{
+			TransformRequest: func(body []byte) ([]byte, error) {
+				if err := json.Unmarshal(body, &request); err != nil {
+					return nil, err
+				}
+				if previous, _ := request["previous_response_id"].(string); previous != "" {
+					history, ok := s.responses.get(previous, key)
+					if !ok {
+						return nil, fmt.Errorf("previous response %q not found", previous)
+					}
+					conversation = slices.Clone(history)
+				}
+				conversation = append(conversation, adapter.ResponsesInputToMessages(request["input"])...)
+				return json.Marshal(adapter.ConvertRequestResponsesToOpenAI(request, conversation))
+			},
+			TransformResponse: func(w http.ResponseWriter, resp *http.Response) error {
+				final, err := adapter.TransformOpenAIResponseToResponses(w, resp, request, id)
+				if err != nil || final == nil {
+					return err
+				}
+				if store, ok := request["store"].(bool); !ok || store {
+					s.responses.put(id, key, append(conversation, adapter.ResponsesInputToMessages(final["output"])...))
+				}
+				return nil
+			},
+		}
+		if err := s.forward(w, r, "/chat/completions", opts); err != nil {
+			log.Printf("responses proxy error [%s]: %v", key, err)
+			s.writeForwardError(w, r, err)
+		}
+	}
+}
@@ -10,17 +10,70 @@ import (
 // Routes 는 인증과 CORS 가 적용된 HTTP 라우팅 구성을 반환합니다.
 func (s *ProxyServer) Routes() http.Handler {
 	mux := http.NewServeMux()
-	chatHandler := s.withAuth(s.proxyHandler("https://api.githubcopilot.com/chat/completions"))
-	embeddingsHandler := s.withAuth(s.proxyHandler("https://api.githubcopilot.com/embeddings"))
-	messagesHandler := s.withAuth(s.messagesHandler())
+	chatHandler := s.guard(dialectOpenAI, endpointChat, s.proxyHandler("/chat/completions"))
+	embeddingsHandler := s.guard(dialectOpenAI, endpointEmbeddings, s.proxyHandler("/embeddings"))
+	completionsHandler := s.guard(dialectOpenAI, endpointCompletions, s.completionsHandler())
+	responsesHandler := s.guard(dialectOpenAI, endpointResponses, s.responsesHandler())
+	messagesHandler := s.guard(dialectAnthropic, endpointChat, s.messagesHandler())
+	completeHandler := s.guard(dialectAnthropic, endpointComplete, s.completeHandler())
+	geminiHandler := s.guard(dialectGemini, endpointGemini, s.geminiHandler())
+	countTokensHandler := s.withAuth(dialectAnthropic, s.withPolicy(dialectAnthropic, endpointCountTokens, s.countTokensHandler()))
+	modelsHandler := byAnthropicVersion(s.withAuth(dialectOpenAI, s.modelsHandler()), s.withAuth(dialectAnthropic, s.modelsHandler()))
 
 	mux.Handle("/chat/completions", chatHandler)
+	mux.Handle("/completions", completionsHandler)
 	mux.Handle("/embeddings", embeddingsHandler)
+	mux.Handle("/responses", responsesHandler)
 	mux.Handle("/messages", messagesHandler)
+	mux.Handle("/messages/count_tokens", countTokensHandler)
+	mux.Handle("/complete", completeHandler)
+	mux.Handle("GET /models", modelsHandler)
+	mux.Handle("GET /models/{id}", modelsHandler)
 
 	mux.Handle("/v1/chat/completions", chatHandler)
+	mux.Handle("/v1/completions", completionsHandler)
 	mux.Handle("/v1/embeddings", embeddingsHandler)
+	mux.Handle("/v1/responses", responsesHandler)
 	mux.Handle("/v1/messages", messagesHandler)
+	mux.Handle("/v1/messages/count_tokens", countTokensHandler)
+	mux.Handle("/v1/complete", completeHandler)
+	mux.Handle("GET /v1/models", modelsHandler)
+	mux.Handle("GET /v1/models/{id}", modelsHandler)
+
+	mux.Handle("POST /v1beta/models/{target}", geminiHandler)
+
+	mux.Handle("/health", s.healthHandler())
+
+	if s.config.AdminToken != "" {
+		mux.Handle("GET /admin/health", s.withAdminAuth(s.adminHealthHandler()))
+	}
+	if s.config.AdminToken != "" && s.config.Usage != nil {
+		mux.Handle("GET /admin/usage", s.withAdminAuth(s.usageHandler()))
+	}
+	if s.config.AdminToken != "" && s.config.Keys != nil {
+		mux.Handle("GET /admin/keys", s.withAdminAuth(s.listKeysHandler()))
+		mux.Handle("POST /admin/keys", s.withAdminAuth(s.createKeyHandler()))
+		mux.Handle("PATCH /admin/keys/{name}", s.withAdminAuth(s.updateKeyHandler()))
+		mux.Handle("DELETE /admin/keys/{name}", s.withAdminAuth(s.revokeKeyHandler()))
+	}
 
 	return httpx.WithCORS(mux)
 }
+
+// guard wraps a client-facing handler with authentication, key policy, quotas and rate limiting for the given dialect and endpoint.
+// guard 는 클라이언트용 핸들러를 해당 형식과 엔드포인트의 인증, 키 정책, 할당량, 요청 한도로 감쌉니다.
+func (s *ProxyServer) guard(d dialect, e endpoint, next http.Handler) http.Handler {
+	return s.withAuth(d, s.withPolicy(d, e, s.withQuota(d, s.withRateLimit(d, next))))
+}
+
+// byAnthropicVersion serves requests carrying an anthropic-version header with anthropic and all others with openai.
+// byAnthropicVersion 는 anthropic-version 헤더가 있는 요청은 anthropic 으로, 나머지는 openai 로 처리합니다.
+func byAnthropicVersion(openai, anthropic http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("anthropic-version") != "" {
+			anthropic.ServeHTTP(w, r)
+			return
+		}
+		openai.ServeHTTP(w, r)
+	})
+}
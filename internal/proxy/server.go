@@ -2,24 +2,62 @@ package proxy
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/ilcm96/gh-copilot-proxy/internal/auth"
+	"github.com/ilcm96/gh-copilot-proxy/internal/usage"
 )
 
 // ProxyServer forwards requests to the Copilot API.
 // ProxyServer 는 Copilot API 로 요청을 전달합니다.
 type ProxyServer struct {
-	auth        *auth.CopilotAuth
+	auth        *auth.Pool
 	accessToken string
+	config      Config
 	client      *http.Client
+	limiter     rateLimiter
+	catalog     modelCatalog
+	responses   responseStore
+
+	quotaWarnings quotaWarnings
+}
+
+// Config holds optional ProxyServer settings.
+// Config 는 ProxyServer 의 선택적 설정을 담습니다.
+type Config struct {
+	// UserTokenHeader names the header carrying a client's own GitHub OAuth token; empty disables passthrough.
+	// UserTokenHeader 는 클라이언트 자신의 GitHub OAuth 토큰을 담는 헤더 이름이며, 비어 있으면 전달 모드를 끕니다.
+	UserTokenHeader string
+	// Keys holds named client keys accepted in addition to the single access token; nil disables them.
+	// Keys 는 단일 접근 토큰 외에 허용할 이름 있는 클라이언트 키들이며, nil 이면 사용하지 않습니다.
+	Keys *KeyStore
+	// AdminToken is the bearer token for the /admin API; empty disables it. The API requires Keys.
+	// AdminToken 는 /admin API 용 Bearer 토큰이며, 비어 있으면 API 를 끕니다. API 는 Keys 가 있어야 합니다.
+	AdminToken string
+	// RateLimit applies to keys without their own rate_limit; the zero value disables it.
+	// RateLimit 는 자체 rate_limit 이 없는 키에 적용되며, 0 값이면 끕니다.
+	RateLimit RateLimit
+	// Usage records per-request usage for reporting; nil disables it.
+	// Usage 는 보고용으로 요청별 사용량을 기록하며, nil 이면 기록하지 않습니다.
+	Usage *usage.Ledger
+	// ClientCertIdentity lets a verified TLS client certificate identify the caller as "cert:<subject>" without a bearer key.
+	// ClientCertIdentity 는 검증된 TLS 클라이언트 인증서만으로 호출자를 "cert:<subject>" 로 식별하며 Bearer 키가 필요 없습니다.
+	ClientCertIdentity bool
+	// ClientCertBindKeys requires both a verified TLS client certificate and a bearer key whose owner is the certificate subject.
+	// ClientCertBindKeys 는 검증된 TLS 클라이언트 인증서와, 소유자가 그 인증서 주체인 Bearer 키를 함께 요구합니다.
+	ClientCertBindKeys bool
+	// ModelsTTL is how long the Copilot model catalog is cached; zero uses five minutes.
+	// ModelsTTL 는 Copilot 모델 목록을 캐시하는 시간이며, 0 이면 5분을 사용합니다.
+	ModelsTTL time.Duration
 }
 
 // NewProxyServer creates a ProxyServer that forwards requests to the Copilot API.
 // NewProxyServer 는 Copilot API 로 요청을 전달하는 ProxyServer 를 생성합니다.
-func NewProxyServer(authenticator *auth.CopilotAuth, accessToken string) *ProxyServer {
+func NewProxyServer(authenticator *auth.Pool, accessToken string, config Config) *ProxyServer {
 	return &ProxyServer{
 		auth:        authenticator,
 		accessToken: accessToken,
+		config:      config,
 		client: &http.Client{
 			Timeout: 0,
 		},
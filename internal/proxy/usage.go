@@ -0,0 +1,101 @@
+package proxy
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ilcm96/gh-copilot-proxy/internal/usage"
+)
+
+// recordUsage appends the usage of a finished upstream response to the ledger.
+// recordUsage 는 끝난 업스트림 응답의 사용량을 사용량 기록에 덧붙입니다.
+func (s *ProxyServer) recordUsage(r *http.Request, body []byte, status int, meter *usage.Meter, start time.Time) {
+	model, input, output, cacheRead := meter.Usage()
+	if model == "" {
+		var req struct {
+			Model string `json:"model"`
+		}
+		_ = json.Unmarshal(body, &req)
+		model = req.Model
+	}
+	rec := usage.Record{
+		Time:            start,
+		Key:             keyName(r.Context()),
+		Model:           model,
+		Route:           r.URL.Path,
+		Status:          status,
+		LatencyMs:       time.Since(start).Milliseconds(),
+		InputTokens:     input,
+		OutputTokens:    output,
+		CacheReadTokens: cacheRead,
+	}
+	if err := s.config.Usage.Append(rec); err != nil {
+		log.Printf("usage ledger: %v", err)
+	}
+}
+
+// usageHandler reports aggregated usage as JSON, or as CSV with format=csv.
+// usageHandler 는 집계된 사용량을 JSON 으로, format=csv 이면 CSV 로 보고합니다.
+//
+// Query parameters: from and to (YYYY-MM-DD, inclusive), key, model and group_by (default "key,model,day").
+// 쿼리 파라미터: from, to (YYYY-MM-DD, 양 끝 포함), key, model, group_by (기본값 "key,model,day").
+func (s *ProxyServer) usageHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+		q := usage.Query{Key: params.Get("key"), Model: params.Get("model"), GroupBy: []string{"key", "model", "day"}}
+		for name, dst := range map[string]*time.Time{"from": &q.From, "to": &q.To} {
+			if v := params.Get(name); v != "" {
+				t, err := time.Parse(time.DateOnly, v)
+				if err != nil {
+					writeAdminError(w, http.StatusBadRequest, name+" must be YYYY-MM-DD")
+					return
+				}
+				*dst = t
+			}
+		}
+		if v := params.Get("group_by"); v != "" {
+			q.GroupBy = nil
+			for _, field := range strings.Split(v, ",") {
+				field = strings.TrimSpace(field)
+				if field != "key" && field != "model" && field != "day" {
+					writeAdminError(w, http.StatusBadRequest, "group_by accepts key, model and day")
+					return
+				}
+				q.GroupBy = append(q.GroupBy, field)
+			}
+		}
+
+		rows := s.config.Usage.Summarize(q)
+		if params.Get("format") == "csv" {
+			writeUsageCSV(w, rows)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"usage": rows})
+	}
+}
+
+// writeUsageCSV writes aggregated usage rows as CSV.
+// writeUsageCSV 는 집계된 사용량 행을 CSV 로 작성합니다.
+func writeUsageCSV(w http.ResponseWriter, rows []usage.Row) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="usage.csv"`)
+	out := csv.NewWriter(w)
+	_ = out.Write([]string{"day", "key", "model", "requests", "errors", "input_tokens", "output_tokens", "cache_read_tokens", "latency_ms"})
+	for _, row := range rows {
+		_ = out.Write([]string{
+			row.Day, row.Key, row.Model,
+			strconv.FormatInt(row.Requests, 10),
+			strconv.FormatInt(row.Errors, 10),
+			strconv.FormatInt(row.InputTokens, 10),
+			strconv.FormatInt(row.OutputTokens, 10),
+			strconv.FormatInt(row.CacheReadTokens, 10),
+			strconv.FormatInt(row.LatencyMs, 10),
+		})
+	}
+	out.Flush()
+}
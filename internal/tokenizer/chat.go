@@ -0,0 +1,119 @@
+package tokenizer
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const (
+	// messageOverhead is charged per message for the role and separators of the chat template.
+	// messageOverhead 는 chat 템플릿의 역할과 구분자 몫으로 메시지마다 매기는 토큰 수입니다.
+	messageOverhead = 3
+	// replyPriming is charged once for the assistant turn the model is primed with.
+	// replyPriming 는 모델이 이어 쓸 assistant 차례 몫으로 한 번 매기는 토큰 수입니다.
+	replyPriming = 3
+	// toolOverhead is charged per tool definition or tool call for its framing.
+	// toolOverhead 는 도구 정의나 도구 호출의 틀 몫으로 하나마다 매기는 토큰 수입니다.
+	toolOverhead = 8
+	// claudeToolPrompt is the system prompt Anthropic adds when tools are present, as documented for tool_choice auto.
+	// claudeToolPrompt 는 도구가 있을 때 Anthropic 이 덧붙이는 시스템 프롬프트의 토큰 수이며, tool_choice auto 기준 문서 값입니다.
+	claudeToolPrompt = 346
+)
+
+// imageTokens is the flat charge per image, since image sizes are not inspected.
+// imageTokens 는 이미지 크기를 확인하지 않으므로 이미지 하나에 일괄로 매기는 토큰 수입니다.
+var imageTokens = map[Family]int{
+	FamilyO200K:  765,
+	FamilyCL100K: 765,
+	FamilyClaude: 1600,
+}
+
+// CountChat estimates the input tokens of an OpenAI chat/completions request, including tool definitions.
+// CountChat 는 도구 정의를 포함해 OpenAI chat/completions 요청의 입력 토큰 수를 추정합니다.
+func CountChat(req map[string]any) int {
+	model, _ := req["model"].(string)
+	f := ForModel(model)
+
+	total := replyPriming
+	messages := toSlice(req["messages"])
+	for _, raw := range messages {
+		msg, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		total += messageOverhead
+		if name, _ := msg["name"].(string); name != "" {
+			total += Count(f, name) + 1
+		}
+		total += countContent(f, msg["content"])
+		calls := toSlice(msg["tool_calls"])
+		for _, raw := range calls {
+			call, _ := raw.(map[string]any)
+			function, _ := call["function"].(map[string]any)
+			name, _ := function["name"].(string)
+			arguments, _ := function["arguments"].(string)
+			total += toolOverhead + Count(f, name) + Count(f, arguments)
+		}
+		if id, _ := msg["tool_call_id"].(string); id != "" {
+			total += Count(f, id)
+		}
+	}
+
+	tools := toSlice(req["tools"])
+	for _, raw := range tools {
+		tool, _ := raw.(map[string]any)
+		function, _ := tool["function"].(map[string]any)
+		name, _ := function["name"].(string)
+		description, _ := function["description"].(string)
+		total += toolOverhead + Count(f, name) + Count(f, description)
+		if parameters, ok := function["parameters"]; ok && parameters != nil {
+			schema, _ := json.Marshal(parameters)
+			total += Count(f, string(schema))
+		}
+	}
+	if len(tools) > 0 && f == FamilyClaude {
+		total += claudeToolPrompt
+	}
+	return total
+}
+
+// countContent estimates a message content that is either a string or a list of parts.
+// countContent 는 문자열이거나 파트 목록인 메시지 콘텐츠의 토큰 수를 추정합니다.
+func countContent(f Family, content any) int {
+	switch v := content.(type) {
+	case string:
+		return Count(f, v)
+	case []any, []map[string]any:
+		var texts []string
+		total := 0
+		for _, raw := range toSlice(v) {
+			part, _ := raw.(map[string]any)
+			switch part["type"] {
+			case "image_url":
+				total += imageTokens[f]
+			default:
+				if text, ok := part["text"].(string); ok {
+					texts = append(texts, text)
+				}
+			}
+		}
+		return total + Count(f, strings.Join(texts, "\n"))
+	}
+	return 0
+}
+
+// toSlice returns the elements of a decoded JSON array or of a slice of objects built in Go.
+// toSlice 는 디코딩된 JSON 배열이나 Go 에서 만든 객체 슬라이스의 원소를 반환합니다.
+func toSlice(v any) []any {
+	switch t := v.(type) {
+	case []any:
+		return t
+	case []map[string]any:
+		out := make([]any, len(t))
+		for i, m := range t {
+			out[i] = m
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package tokenizer
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCountContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		family  Family
+		content any
+		want    int
+	}{
+		{name: "string", family: FamilyO200K, content: "hello world", want: 2},
+		{name: "missing", family: FamilyO200K, content: nil, want: 0},
+		{
+			name: "text parts are joined", family: FamilyO200K,
+			content: []any{
+				map[string]any{"type": "text", "text": "hello"},
+				map[string]any{"type": "text", "text": "world"},
+			},
+			want: Count(FamilyO200K, "hello\nworld"),
+		},
+		{
+			name: "parts built in Go", family: FamilyCL100K,
+			content: []map[string]any{{"type": "text", "text": "hello world"}},
+			want:    2,
+		},
+		{
+			name: "image parts get the flat charge", family: FamilyO200K,
+			content: []any{
+				map[string]any{"type": "text", "text": "hello world"},
+				map[string]any{"type": "image_url", "image_url": map[string]any{"url": "data:image/png;base64,AA"}},
+				map[string]any{"type": "image_url", "image_url": map[string]any{"url": "https://example.com/a.png"}},
+			},
+			want: 2 + 2*765,
+		},
+		{
+			name: "claude images cost more", family: FamilyClaude,
+			content: []any{map[string]any{"type": "image_url", "image_url": map[string]any{"url": "data:image/png;base64,AA"}}},
+			want:    1600,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countContent(tt.family, tt.content); got != tt.want {
+				t.Errorf("countContent() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountChat(t *testing.T) {
+	weather := `{"type":"function","function":{"name":"get_weather","description":"Look up the weather","parameters":{"type":"object","properties":{"city":{"type":"string"}}}}}`
+	schema := `{"properties":{"city":{"type":"string"}},"type":"object"}`
+	tools := func(f Family) int {
+		return toolOverhead + Count(f, "get_weather") + Count(f, "Look up the weather") + Count(f, schema)
+	}
+	tests := []struct {
+		name string
+		req  string
+		want int
+	}{
+		{
+			name: "empty request is the reply priming",
+			req:  `{"model":"gpt-4o"}`,
+			want: replyPriming,
+		},
+		{
+			name: "message overhead and name",
+			req:  `{"model":"gpt-4o","messages":[{"role":"user","name":"alice","content":"hello world"}]}`,
+			want: replyPriming + messageOverhead + Count(FamilyO200K, "alice") + 1 + 2,
+		},
+		{
+			name: "system blocks are counted like other content",
+			req:  `{"model":"claude-sonnet-4","messages":[{"role":"system","content":[{"type":"text","text":"Be brief."},{"type":"text","text":"Answer in Korean."}]},{"role":"user","content":"hello world"}]}`,
+			want: replyPriming + 2*messageOverhead + Count(FamilyClaude, "Be brief.\nAnswer in Korean.") + 2,
+		},
+		{
+			name: "tool definitions",
+			req:  `{"model":"gpt-4o","messages":[{"role":"user","content":"hello world"}],"tools":[` + weather + `]}`,
+			want: replyPriming + messageOverhead + 2 + tools(FamilyO200K),
+		},
+		{
+			name: "claude tool definitions add the tool system prompt",
+			req:  `{"model":"claude-sonnet-4","messages":[{"role":"user","content":"hello world"}],"tools":[` + weather + `]}`,
+			want: replyPriming + messageOverhead + 2 + tools(FamilyClaude) + claudeToolPrompt,
+		},
+		{
+			name: "tool calls and results",
+			req:  `{"model":"gpt-4o","messages":[{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Seoul\"}"}}]},{"role":"tool","tool_call_id":"call_1","content":"sunny"}]}`,
+			want: replyPriming + 2*messageOverhead +
+				toolOverhead + Count(FamilyO200K, "get_weather") + Count(FamilyO200K, `{"city":"Seoul"}`) +
+				Count(FamilyO200K, "call_1") + Count(FamilyO200K, "sunny"),
+		},
+		{
+			name: "image parts",
+			req:  `{"model":"gpt-4o","messages":[{"role":"user","content":[{"type":"text","text":"hello world"},{"type":"image_url","image_url":{"url":"data:image/png;base64,AA"}}]}]}`,
+			want: replyPriming + messageOverhead + 2 + 765,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req map[string]any
+			if err := json.Unmarshal([]byte(tt.req), &req); err != nil {
+				t.Fatal(err)
+			}
+			if got := CountChat(req); got != tt.want {
+				t.Errorf("CountChat() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
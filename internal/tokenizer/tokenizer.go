@@ -0,0 +1,76 @@
+// Package tokenizer counts tokens offline with the BPE vocabularies of the model families.
+// Package tokenizer 는 모델 계열의 BPE 어휘로 오프라인에서 토큰 수를 셉니다.
+//
+// OpenAI models are counted exactly with the embedded o200k_base and cl100k_base rank tables.
+// Anthropic does not publish the Claude vocabulary, so Claude models are counted with cl100k_base,
+// which makes their counts an estimate.
+// OpenAI 모델은 내장된 o200k_base, cl100k_base 순위 테이블로 정확히 셉니다. Anthropic 은 Claude 어휘를
+// 공개하지 않으므로 Claude 모델은 cl100k_base 로 세며, 그 결과는 추정값입니다.
+package tokenizer
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/tiktoken-go/tokenizer/codec"
+)
+
+// Family names a tokenizer family.
+// Family 는 토크나이저 계열의 이름입니다.
+type Family string
+
+const (
+	// FamilyO200K covers the GPT-4o, GPT-4.1, GPT-5 and o-series vocabularies.
+	// FamilyO200K 는 GPT-4o, GPT-4.1, GPT-5, o 시리즈 어휘입니다.
+	FamilyO200K Family = "o200k"
+	// FamilyCL100K covers GPT-4 and GPT-3.5.
+	// FamilyCL100K 는 GPT-4 와 GPT-3.5 입니다.
+	FamilyCL100K Family = "cl100k"
+	// FamilyClaude covers Anthropic Claude models.
+	// FamilyClaude 는 Anthropic Claude 모델입니다.
+	FamilyClaude Family = "claude"
+)
+
+// The rank tables are large, so each codec is built on first use.
+// 순위 테이블이 크므로 각 코덱은 처음 쓸 때 만듭니다.
+var (
+	o200kBase  = sync.OnceValue(codec.NewO200kBase)
+	cl100kBase = sync.OnceValue(codec.NewCl100kBase)
+)
+
+// ForModel returns the tokenizer family of a model name; unknown models use o200k.
+// ForModel 는 모델 이름에 맞는 토크나이저 계열을 반환하며, 모르는 모델은 o200k 를 사용합니다.
+func ForModel(model string) Family {
+	model = strings.ToLower(model)
+	switch {
+	case strings.Contains(model, "claude"):
+		return FamilyClaude
+	case strings.HasPrefix(model, "gpt-4o"), strings.HasPrefix(model, "gpt-4.1"), strings.HasPrefix(model, "gpt-5"):
+		return FamilyO200K
+	case strings.HasPrefix(model, "gpt-4"), strings.HasPrefix(model, "gpt-3.5"), strings.HasPrefix(model, "text-embedding"):
+		return FamilyCL100K
+	default:
+		return FamilyO200K
+	}
+}
+
+// codecFor returns the BPE codec used to count tokens for the family.
+// codecFor 는 해당 계열의 토큰 수를 셀 때 쓰는 BPE 코덱을 반환합니다.
+func codecFor(f Family) *codec.Codec {
+	if f == FamilyCL100K || f == FamilyClaude {
+		return cl100kBase()
+	}
+	return o200kBase()
+}
+
+// Count returns the number of tokens in text for the family.
+// Count 는 해당 계열에서 text 의 토큰 수를 반환합니다.
+func Count(f Family, text string) int {
+	if text == "" {
+		return 0
+	}
+	// The split pattern only fails on a match timeout, which is not set, so the count is always complete.
+	// 분할 패턴은 일치 시간 제한에서만 실패하는데 제한을 두지 않으므로 항상 끝까지 셉니다.
+	n, _ := codecFor(f).Count(text)
+	return n
+}
@@ -0,0 +1,52 @@
+package tokenizer
+
+import "testing"
+
+func TestForModel(t *testing.T) {
+	tests := []struct {
+		model string
+		want  Family
+	}{
+		{"gpt-4o", FamilyO200K},
+		{"gpt-4o-mini", FamilyO200K},
+		{"GPT-4o-2024-11-20", FamilyO200K},
+		{"gpt-4.1", FamilyO200K},
+		{"gpt-5", FamilyO200K},
+		{"o3-mini", FamilyO200K},
+		{"gpt-4", FamilyCL100K},
+		{"gpt-4-0613", FamilyCL100K},
+		{"gpt-3.5-turbo", FamilyCL100K},
+		{"text-embedding-3-small", FamilyCL100K},
+		{"claude-sonnet-4", FamilyClaude},
+		{"claude-3.5-sonnet", FamilyClaude},
+		{"Claude-Opus-4", FamilyClaude},
+		{"", FamilyO200K},
+		{"gemini-2.5-pro", FamilyO200K},
+	}
+	for _, tt := range tests {
+		if got := ForModel(tt.model); got != tt.want {
+			t.Errorf("ForModel(%q) = %s, want %s", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		family Family
+		text   string
+		want   int
+	}{
+		{FamilyO200K, "", 0},
+		{FamilyO200K, "hello world", 2},
+		{FamilyCL100K, "hello world", 2},
+		// The vocabularies split some text differently.
+		{FamilyO200K, "안녕하세요", 2},
+		{FamilyCL100K, "안녕하세요", 5},
+		{FamilyClaude, "안녕하세요", 5},
+	}
+	for _, tt := range tests {
+		if got := Count(tt.family, tt.text); got != tt.want {
+			t.Errorf("Count(%s, %q) = %d, want %d", tt.family, tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dayLayout is the format of the day buckets, always in UTC.
+// dayLayout 는 항상 UTC 기준인 일 단위 집계 키의 형식입니다.
+const dayLayout = "2006-01-02"
+
+// Record is the usage of a single proxied request.
+// Record 는 프록시한 요청 한 건의 사용량입니다.
+type Record struct {
+	Time            time.Time `json:"time"`
+	Key             string    `json:"key"`
+	Model           string    `json:"model"`
+	Route           string    `json:"route"`
+	Status          int       `json:"status"`
+	LatencyMs       int64     `json:"latency_ms"`
+	InputTokens     int64     `json:"input_tokens"`
+	OutputTokens    int64     `json:"output_tokens"`
+	CacheReadTokens int64     `json:"cache_read_tokens"`
+}
+
+// Totals are summed usage counters.
+// Totals 는 합산된 사용량 카운터입니다.
+type Totals struct {
+	Requests        int64 `json:"requests"`
+	Errors          int64 `json:"errors"`
+	InputTokens     int64 `json:"input_tokens"`
+	OutputTokens    int64 `json:"output_tokens"`
+	CacheReadTokens int64 `json:"cache_read_tokens"`
+	LatencyMs       int64 `json:"latency_ms"`
+}
+
+// Tokens returns the input and output tokens together.
+// Tokens 는 입력과 출력 토큰의 합을 반환합니다.
+func (t Totals) Tokens() int64 {
+	return t.InputTokens + t.OutputTokens
+}
+
+// add accumulates a record into the totals.
+// add 는 레코드를 합계에 더합니다.
+func (t *Totals) add(rec Record) {
+	t.Requests++
+	if rec.Status >= 400 || rec.Status == 0 {
+		t.Errors++
+	}
+	t.InputTokens += rec.InputTokens
+	t.OutputTokens += rec.OutputTokens
+	t.CacheReadTokens += rec.CacheReadTokens
+	t.LatencyMs += rec.LatencyMs
+}
+
+// merge adds other into the totals.
+// merge 는 other 를 합계에 더합니다.
+func (t *Totals) merge(other Totals) {
+	t.Requests += other.Requests
+	t.Errors += other.Errors
+	t.InputTokens += other.InputTokens
+	t.OutputTokens += other.OutputTokens
+	t.CacheReadTokens += other.CacheReadTokens
+	t.LatencyMs += other.LatencyMs
+}
+
+// bucketKey identifies the totals of one key and model on one day.
+// bucketKey 는 하루 동안 키와 모델 하나의 합계를 식별합니다.
+type bucketKey struct {
+	Day   string
+	Key   string
+	Model string
+}
+
+// Ledger appends usage records to a JSON Lines file and keeps daily totals in memory.
+// Ledger 는 사용량 레코드를 JSON Lines 파일에 덧붙이고 일별 합계를 메모리에 유지합니다.
+type Ledger struct {
+	mu     sync.RWMutex
+	file   *os.File
+	totals map[bucketKey]*Totals
+	// keyDays holds the same totals per key and day, so quota checks do not scan every bucket.
+	// keyDays 는 같은 합계를 키와 날짜별로 담아, 할당량 확인이 모든 버킷을 훑지 않게 합니다.
+	keyDays map[string]map[string]*Totals
+}
+
+// Open opens or creates the ledger at path and rebuilds the daily totals from it.
+// Open 는 path 의 사용량 기록을 열거나 만들고, 그 내용으로 일별 합계를 다시 계산합니다.
+func Open(path string) (*Ledger, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("create usage ledger directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open usage ledger: %w", err)
+	}
+	l := &Ledger{file: file, totals: make(map[bucketKey]*Totals), keyDays: make(map[string]map[string]*Totals)}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	count, skipped := 0, 0
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			skipped++
+			continue
+		}
+		l.add(rec)
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("read usage ledger: %w", err)
+	}
+	if skipped > 0 {
+		log.Printf("usage ledger %s: skipped %d unreadable line(s)", path, skipped)
+	}
+	log.Printf("usage ledger %s: loaded %d record(s)", path, count)
+	return l, nil
+}
+
+// Close closes the ledger file.
+// Close 는 사용량 기록 파일을 닫습니다.
+func (l *Ledger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// Append writes a record to the ledger and adds it to the totals.
+// Append 는 레코드를 사용량 기록에 쓰고 합계에 더합니다.
+func (l *Ledger) Append(rec Record) error {
+	rec.Time = rec.Time.UTC()
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.add(rec)
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write usage ledger: %w", err)
+	}
+	return nil
+}
+
+// add accumulates a record into its daily buckets; the caller holds mu.
+// add 는 레코드를 해당 일별 버킷에 더하며, 호출자가 mu 를 잡고 있어야 합니다.
+func (l *Ledger) add(rec Record) {
+	k := bucketKey{Day: rec.Time.UTC().Format(dayLayout), Key: rec.Key, Model: rec.Model}
+	t, ok := l.totals[k]
+	if !ok {
+		t = &Totals{}
+		l.totals[k] = t
+	}
+	t.add(rec)
+
+	days, ok := l.keyDays[k.Key]
+	if !ok {
+		days = make(map[string]*Totals)
+		l.keyDays[k.Key] = days
+	}
+	day, ok := days[k.Day]
+	if !ok {
+		day = &Totals{}
+		days[k.Day] = day
+	}
+	day.add(rec)
+}
+
+// Query selects and groups ledger totals; zero fields do not filter.
+// Query 는 사용량 합계를 고르고 묶는 조건이며, 0 값 필드는 거르지 않습니다.
+type Query struct {
+	// From and To bound the days included, inclusive.
+	// From 과 To 는 포함할 날짜의 범위이며 양 끝을 포함합니다.
+	From, To time.Time
+	Key      string
+	Model    string
+	// GroupBy lists the dimensions to keep: "key", "model" and/or "day".
+	// GroupBy 는 유지할 기준이며 "key", "model", "day" 중에서 고릅니다.
+	GroupBy []string
+}
+
+// Row is one group of aggregated usage; dimensions not grouped by are empty.
+// Row 는 집계된 사용량 한 묶음이며, 묶지 않은 기준은 비어 있습니다.
+type Row struct {
+	Day   string `json:"day,omitempty"`
+	Key   string `json:"key,omitempty"`
+	Model string `json:"model,omitempty"`
+	Totals
+}
+
+// Summarize aggregates the daily totals that match q, sorted by day, key and model.
+// Summarize 는 q 에 맞는 일별 합계를 집계하며, 날짜, 키, 모델 순으로 정렬합니다.
+func (l *Ledger) Summarize(q Query) []Row {
+	from, to := "", ""
+	if !q.From.IsZero() {
+		from = q.From.UTC().Format(dayLayout)
+	}
+	if !q.To.IsZero() {
+		to = q.To.UTC().Format(dayLayout)
+	}
+	byDay, byKey, byModel := slices.Contains(q.GroupBy, "day"), slices.Contains(q.GroupBy, "key"), slices.Contains(q.GroupBy, "model")
+
+	groups := make(map[bucketKey]*Row)
+	l.mu.RLock()
+	for k, t := range l.totals {
+		if (from != "" && k.Day < from) || (to != "" && k.Day > to) {
+			continue
+		}
+		if (q.Key != "" && k.Key != q.Key) || (q.Model != "" && k.Model != q.Model) {
+			continue
+		}
+		var g bucketKey
+		if byDay {
+			g.Day = k.Day
+		}
+		if byKey {
+			g.Key = k.Key
+		}
+		if byModel {
+			g.Model = k.Model
+		}
+		row, ok := groups[g]
+		if !ok {
+			row = &Row{Day: g.Day, Key: g.Key, Model: g.Model}
+			groups[g] = row
+		}
+		row.merge(*t)
+	}
+	l.mu.RUnlock()
+
+	rows := make([]Row, 0, len(groups))
+	for _, row := range groups {
+		rows = append(rows, *row)
+	}
+	slices.SortFunc(rows, func(a, b Row) int {
+		return strings.Compare(a.Day+"\x00"+a.Key+"\x00"+a.Model, b.Day+"\x00"+b.Key+"\x00"+b.Model)
+	})
+	return rows
+}
+
+// Since returns the totals of key from the UTC day containing since onwards.
+// Since 는 since 가 속한 UTC 날짜부터 key 의 합계를 반환합니다.
+func (l *Ledger) Since(key string, since time.Time) Totals {
+	from := since.UTC().Format(dayLayout)
+	var total Totals
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for day, t := range l.keyDays[key] {
+		if day >= from {
+			total.merge(*t)
+		}
+	}
+	return total
+}
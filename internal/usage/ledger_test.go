@@ -0,0 +1,78 @@
+package usage
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLedgerRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage", "ledger.jsonl")
+	day1 := time.Date(2026, 3, 1, 23, 30, 0, 0, time.UTC)
+	day2 := day1.Add(2 * time.Hour)
+	records := []Record{
+		{Time: day1, Key: "alice", Model: "gpt-4o", Status: 200, LatencyMs: 100, InputTokens: 10, OutputTokens: 5, CacheReadTokens: 2},
+		{Time: day1, Key: "alice", Model: "gpt-4o", Status: 502, LatencyMs: 50},
+		{Time: day1, Key: "bob", Model: "claude-sonnet-4", Status: 200, LatencyMs: 200, InputTokens: 20, OutputTokens: 7},
+		// A local time lands in the UTC day it belongs to.
+		{Time: day2.In(time.FixedZone("KST", 9*60*60)), Key: "alice", Model: "gpt-4o", Status: 200, LatencyMs: 10, InputTokens: 1, OutputTokens: 1},
+	}
+
+	ledger, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	for _, rec := range records {
+		if err := ledger.Append(rec); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	before := ledger.Summarize(Query{GroupBy: []string{"day", "key", "model"}})
+	if err := ledger.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// An unreadable line is skipped on reopen rather than failing the load.
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteString("not json\n"); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	ledger, err = Open(path)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	defer ledger.Close()
+	after := ledger.Summarize(Query{GroupBy: []string{"day", "key", "model"}})
+	if !reflect.DeepEqual(after, before) {
+		t.Errorf("reopened totals = %+v, want %+v", after, before)
+	}
+
+	want := []Row{
+		{Day: "2026-03-01", Key: "alice", Model: "gpt-4o", Totals: Totals{Requests: 2, Errors: 1, InputTokens: 10, OutputTokens: 5, CacheReadTokens: 2, LatencyMs: 150}},
+		{Day: "2026-03-01", Key: "bob", Model: "claude-sonnet-4", Totals: Totals{Requests: 1, InputTokens: 20, OutputTokens: 7, LatencyMs: 200}},
+		{Day: "2026-03-02", Key: "alice", Model: "gpt-4o", Totals: Totals{Requests: 1, InputTokens: 1, OutputTokens: 1, LatencyMs: 10}},
+	}
+	if !reflect.DeepEqual(after, want) {
+		t.Errorf("Summarize() = %+v, want %+v", after, want)
+	}
+
+	if got := ledger.Summarize(Query{Key: "alice"}); len(got) != 1 || got[0].Requests != 3 || got[0].Tokens() != 17 {
+		t.Errorf("Summarize(alice) = %+v, want 3 requests and 17 tokens", got)
+	}
+	if got := ledger.Summarize(Query{From: day2, GroupBy: []string{"key"}}); len(got) != 1 || got[0].Key != "alice" || got[0].Requests != 1 {
+		t.Errorf("Summarize(from day 2) = %+v, want one alice request", got)
+	}
+	if got := ledger.Since("alice", day1); got.Requests != 3 {
+		t.Errorf("Since(alice, day 1) = %+v, want 3 requests", got)
+	}
+	if got := ledger.Since("alice", day2); got.Requests != 1 {
+		t.Errorf("Since(alice, day 2) = %+v, want 1 request", got)
+	}
+}
@@ -0,0 +1,140 @@
+package usage
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// maxMeteredBody bounds how much of a non-streaming response is buffered to find its usage.
+// maxMeteredBody 는 사용량을 찾기 위해 버퍼링할 비스트리밍 응답의 최대 크기입니다.
+const maxMeteredBody = 16 << 20
+
+// openAIUsage is the usage object of OpenAI-style responses and stream chunks.
+// openAIUsage 는 OpenAI 형식 응답과 스트림 청크의 usage 객체입니다.
+type openAIUsage struct {
+	PromptTokens        int64 `json:"prompt_tokens"`
+	CompletionTokens    int64 `json:"completion_tokens"`
+	PromptTokensDetails struct {
+		CachedTokens int64 `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+}
+
+// openAIPayload holds the fields a meter reads from a response body or chunk.
+// openAIPayload 는 미터가 응답 본문이나 청크에서 읽는 필드를 담습니다.
+type openAIPayload struct {
+	Model string       `json:"model"`
+	Usage *openAIUsage `json:"usage"`
+}
+
+// Meter wraps an upstream OpenAI-style response body and picks up the model and token usage as it is read.
+// Meter 는 업스트림 OpenAI 형식 응답 본문을 감싸 읽히는 동안 모델과 토큰 사용량을 수집합니다.
+type Meter struct {
+	body   io.ReadCloser
+	stream bool
+
+	mu       sync.Mutex
+	buf      bytes.Buffer
+	overflow bool
+	done     bool
+	model    string
+	usage    openAIUsage
+}
+
+// NewMeter returns a Meter reading body; stream selects server-sent event parsing.
+// NewMeter 는 body 를 읽는 Meter 를 반환하며, stream 이면 SSE 로 해석합니다.
+func NewMeter(body io.ReadCloser, stream bool) *Meter {
+	return &Meter{body: body, stream: stream}
+}
+
+// Read reads from the wrapped body while observing the bytes.
+// Read 는 감싼 본문을 읽으면서 바이트를 관찰합니다.
+func (m *Meter) Read(p []byte) (int, error) {
+	n, err := m.body.Read(p)
+	if n > 0 {
+		m.observe(p[:n])
+	}
+	return n, err
+}
+
+// Close closes the wrapped body.
+// Close 는 감싼 본문을 닫습니다.
+func (m *Meter) Close() error {
+	return m.body.Close()
+}
+
+// observe buffers data and, for streams, parses every complete line.
+// observe 는 데이터를 버퍼에 담고, 스트림이면 완성된 줄마다 해석합니다.
+func (m *Meter) observe(data []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.stream {
+		if m.buf.Len()+len(data) > maxMeteredBody {
+			m.overflow = true
+			m.buf.Reset()
+		}
+		if !m.overflow {
+			m.buf.Write(data)
+		}
+		return
+	}
+	m.buf.Write(data)
+	for {
+		line, err := m.buf.ReadBytes('\n')
+		if err != nil {
+			// Keep the partial line for the next read.
+			rest := append([]byte(nil), line...)
+			m.buf.Reset()
+			m.buf.Write(rest)
+			return
+		}
+		m.parseLine(line)
+	}
+}
+
+// parseLine reads the model and usage from an SSE data line.
+// parseLine 는 SSE data 줄에서 모델과 사용량을 읽습니다.
+func (m *Meter) parseLine(line []byte) {
+	data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:"))
+	if !ok {
+		return
+	}
+	data = bytes.TrimSpace(data)
+	if m.model != "" && !bytes.Contains(data, []byte(`"usage"`)) {
+		return
+	}
+	m.parse(data)
+}
+
+// parse reads the model and usage from a JSON payload.
+// parse 는 JSON 페이로드에서 모델과 사용량을 읽습니다.
+func (m *Meter) parse(data []byte) {
+	var payload openAIPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return
+	}
+	if payload.Model != "" {
+		m.model = payload.Model
+	}
+	if payload.Usage != nil {
+		m.usage = *payload.Usage
+	}
+}
+
+// Usage returns the model and token counts seen so far, finishing the parse of a buffered body.
+// Usage 는 지금까지 관찰한 모델과 토큰 수를 반환하며, 버퍼에 담긴 본문의 해석을 마칩니다.
+func (m *Meter) Usage() (model string, input, output, cacheRead int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.done {
+		m.done = true
+		if m.stream {
+			m.parseLine(m.buf.Bytes())
+		} else if !m.overflow {
+			m.parse(m.buf.Bytes())
+		}
+		m.buf.Reset()
+	}
+	return m.model, m.usage.PromptTokens, m.usage.CompletionTokens, m.usage.PromptTokensDetails.CachedTokens
+}